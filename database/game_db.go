@@ -14,6 +14,48 @@ type GameDb struct {
 	mutex sync.Mutex
 }
 
+// how the turn to reveal passes between players, KingModeOff lets everyone reveal at any time
+const (
+	KingModeOff = iota
+	KingModeInOrder
+	KingModeRandom
+	KingModePickedByDare
+)
+
+// how the players are drawn for dares, the idle count is the number of dares since the player was named
+const (
+	SelectionPolicyExponential = iota // the chances double with every dare the player wasn't named in
+	SelectionPolicyUniform            // everyone has the same chances
+	SelectionPolicyLinear             // the chances grow by one with every dare the player wasn't named in
+	SelectionPolicyRoundRobin         // no one is named twice until everyone was named
+)
+
+// what a player named in a revealed dare did with it
+const (
+	DareStatePending = iota
+	DareStateDone
+	DareStateSkipped
+)
+
+// rules for the pairs named by the opposite placeholders, they apply only when both players chose the same rule
+const (
+	PairConstraintNone  = iota
+	PairConstraintNever // the players are never named as a pair
+	PairConstraintOnly  // the players are named as a pair only with each other
+)
+
+// categories of the suggested commands in truth or dare mode
+const (
+	AnyCategory   = -1
+	DareCategory  = 0
+	TruthCategory = 1
+)
+
+type CategorizedCommand struct {
+	Command  string
+	Category int
+}
+
 func init() {
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 }
@@ -36,16 +78,29 @@ func ConnectDb(path string) (database *GameDb, err error) {
 	database.db.Exec("CREATE TABLE IF NOT EXISTS" +
 		" sessions(id INTEGER NOT NULL PRIMARY KEY" +
 		",token TEXT NOT NULL" +
+		",host_user_id INTEGER" +
+		",last_activity_time INTEGER" + // unix time of the last action in the session
+		",placeholders TEXT" + // placeholders set by the host, replace the default ones
+		",truth_or_dare_mode INTEGER NOT NULL DEFAULT 0" + // the suggestions are split into truths and dares
+		",king_mode INTEGER NOT NULL DEFAULT 0" + // how the turn passes to the next player, KingMode* values
+		",king_user_id INTEGER" + // the player whose turn it is to reveal
+		",skip_penalty INTEGER NOT NULL DEFAULT 0" + // penalty points a player gets for skipping a dare
+		",selection_policy INTEGER NOT NULL DEFAULT 0" + // how the players are drawn, SelectionPolicy* values
+		",teams_count INTEGER NOT NULL DEFAULT 0" + // how many teams the players were split into, zero if there are no teams
+		",is_web_hosted INTEGER NOT NULL DEFAULT 0" + // created from the web, lives without Telegram users while it has web users
 		")")
 
 	database.db.Exec("CREATE TABLE IF NOT EXISTS" +
 		" users(id INTEGER NOT NULL PRIMARY KEY" +
 		",name TEXT NOT NULL" +
-		",gender INTEGER NOT NULL" +
+		",group_keys TEXT NOT NULL DEFAULT ''" + // keys of the participation groups separated by commas
+		",declined_tags TEXT NOT NULL DEFAULT ''" + // content tags separated by commas, the player is never named in dares with them
 
 		// session related data
 		",current_session INTEGER" +
 		",current_session_idle_count INTEGER NOT NULL" + // how many steps player didn't participate in
+		",is_paused INTEGER NOT NULL DEFAULT 0" + // the player sits out and is not drawn for dares
+		",current_session_team INTEGER NOT NULL DEFAULT 0" + // the team of the player starting from 1, zero if the player is not in a team
 		")")
 
 	database.db.Exec("CREATE TABLE IF NOT EXISTS" +
@@ -69,6 +124,13 @@ func ConnectDb(path string) (database *GameDb, err error) {
 		" session_commands(id INTEGER NOT NULL PRIMARY KEY" +
 		",session_id INTEGER NOT NULL" +
 		",command TEXT NOT NULL" +
+		",category INTEGER NOT NULL DEFAULT 0" + // DareCategory or TruthCategory
+		")")
+
+	database.db.Exec("CREATE TABLE IF NOT EXISTS" +
+		" session_penalty_commands(id INTEGER NOT NULL PRIMARY KEY" +
+		",session_id INTEGER NOT NULL" +
+		",command TEXT NOT NULL" +
 		")")
 
 	database.db.Exec("CREATE TABLE IF NOT EXISTS" +
@@ -78,6 +140,42 @@ func ConnectDb(path string) (database *GameDb, err error) {
 		",message TEXT NOT NULL" +
 		")")
 
+	database.db.Exec("CREATE TABLE IF NOT EXISTS" +
+		" dare_packs(id INTEGER NOT NULL PRIMARY KEY" +
+		",owner_user_id INTEGER NOT NULL" +
+		",name TEXT NOT NULL" +
+		",language TEXT NOT NULL DEFAULT ''" +
+		")")
+
+	database.db.Exec("CREATE TABLE IF NOT EXISTS" +
+		" dare_pack_commands(id INTEGER NOT NULL PRIMARY KEY" +
+		",pack_id INTEGER NOT NULL" +
+		",command TEXT NOT NULL" +
+		",category INTEGER NOT NULL DEFAULT 0" + // DareCategory or TruthCategory
+		")")
+
+	database.db.Exec("CREATE TABLE IF NOT EXISTS" +
+		" revealed_dares(id INTEGER NOT NULL PRIMARY KEY" +
+		",session_id INTEGER NOT NULL" +
+		",command TEXT NOT NULL" + // the dare with the names of the players
+		")")
+
+	database.db.Exec("CREATE TABLE IF NOT EXISTS" +
+		" revealed_dare_players(id INTEGER NOT NULL PRIMARY KEY" +
+		",dare_id INTEGER NOT NULL" +
+		",user_id INTEGER NOT NULL" +
+		",state INTEGER NOT NULL DEFAULT 0" + // DareState* values
+		",penalty_points INTEGER NOT NULL DEFAULT 0" +
+		")")
+
+	database.db.Exec("CREATE TABLE IF NOT EXISTS" +
+		" pair_constraints(id INTEGER NOT NULL PRIMARY KEY" +
+		",session_id INTEGER NOT NULL" +
+		",user_id INTEGER NOT NULL" + // the player who chose the rule
+		",partner_user_id INTEGER NOT NULL" +
+		",kind INTEGER NOT NULL" + // PairConstraint* values
+		")")
+
 	database.db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS" +
 		" token_index ON sessions(token)")
 
@@ -99,9 +197,27 @@ func ConnectDb(path string) (database *GameDb, err error) {
 	database.db.Exec("CREATE INDEX IF NOT EXISTS" +
 		" session_id_index ON session_commands(session_id)")
 
+	database.db.Exec("CREATE INDEX IF NOT EXISTS" +
+		" penalty_session_id_index ON session_penalty_commands(session_id)")
+
 	database.db.Exec("CREATE INDEX IF NOT EXISTS" +
 		" user_id_index ON recent_web_messages(user_id)")
 
+	database.db.Exec("CREATE INDEX IF NOT EXISTS" +
+		" owner_user_id_index ON dare_packs(owner_user_id)")
+
+	database.db.Exec("CREATE INDEX IF NOT EXISTS" +
+		" pack_id_index ON dare_pack_commands(pack_id)")
+
+	database.db.Exec("CREATE INDEX IF NOT EXISTS" +
+		" revealed_dares_session_id_index ON revealed_dares(session_id)")
+
+	database.db.Exec("CREATE INDEX IF NOT EXISTS" +
+		" revealed_dare_players_dare_id_index ON revealed_dare_players(dare_id)")
+
+	database.db.Exec("CREATE INDEX IF NOT EXISTS" +
+		" pair_constraints_session_id_index ON pair_constraints(session_id)")
+
 	return
 }
 
@@ -189,8 +305,8 @@ func (database *GameDb) GetOrCreateTelegramUserId(chatId int64, userLangCode str
 		log.Fatal(err.Error())
 	}
 
-	database.db.Exec(fmt.Sprintf("INSERT INTO users(name, gender, current_session_idle_count) "+
-		"VALUES ('%s', 0, 0)", dbBase.SanitizeString(userName)))
+	database.db.Exec(fmt.Sprintf("INSERT INTO users(name, current_session_idle_count) "+
+		"VALUES ('%s', 0)", dbBase.SanitizeString(userName)))
 
 	userId = database.getLastInsertedItemId()
 
@@ -333,18 +449,66 @@ func (database *GameDb) GetUserLanguage(userId int64) (language string) {
 	return
 }
 
-func (database *GameDb) SetUserGender(userId int64, gender int) {
+func (database *GameDb) SetUserGroups(userId int64, groups []string) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	database.db.Exec(fmt.Sprintf("UPDATE OR ROLLBACK users SET group_keys='%s' WHERE id=%d", dbBase.SanitizeString(strings.Join(groups, ",")), userId))
+}
+
+func (database *GameDb) GetUserGroups(userId int64) (groups []string) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	rows, err := database.db.Query(fmt.Sprintf("SELECT group_keys FROM users WHERE id=%d", userId))
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+	}()
+
+	if rows.Next() {
+		var groupsStr string
+		err := rows.Scan(&groupsStr)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		groups = splitList(groupsStr)
+	} else {
+		err = rows.Err()
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("can't find groups for player %d", userId)
+	}
+
+	return
+}
+
+// splits the values stored separated by commas
+func splitList(list string) []string {
+	if len(list) == 0 {
+		return nil
+	}
+	return strings.Split(list, ",")
+}
+
+func (database *GameDb) SetUserDeclinedTags(userId int64, tags []string) {
 	database.mutex.Lock()
 	defer database.mutex.Unlock()
 
-	database.db.Exec(fmt.Sprintf("UPDATE OR ROLLBACK users SET gender='%d' WHERE id=%d", gender, userId))
+	database.db.Exec(fmt.Sprintf("UPDATE OR ROLLBACK users SET declined_tags='%s' WHERE id=%d", dbBase.SanitizeString(strings.Join(tags, ",")), userId))
 }
 
-func (database *GameDb) GetUserGender(userId int64) (gender int) {
+func (database *GameDb) GetUserDeclinedTags(userId int64) (tags []string) {
 	database.mutex.Lock()
 	defer database.mutex.Unlock()
 
-	rows, err := database.db.Query(fmt.Sprintf("SELECT gender FROM users WHERE id=%d", userId))
+	rows, err := database.db.Query(fmt.Sprintf("SELECT declined_tags FROM users WHERE id=%d", userId))
 	if err != nil {
 		log.Fatal(err.Error())
 	}
@@ -356,16 +520,17 @@ func (database *GameDb) GetUserGender(userId int64) (gender int) {
 	}()
 
 	if rows.Next() {
-		err := rows.Scan(&gender)
+		var declinedTags string
+		err := rows.Scan(&declinedTags)
 		if err != nil {
 			log.Fatal(err.Error())
 		}
+		tags = splitList(declinedTags)
 	} else {
 		err = rows.Err()
 		if err != nil {
 			log.Fatal(err)
 		}
-		log.Printf("can't find gender for player %d", userId)
 	}
 
 	return
@@ -470,32 +635,36 @@ func (database *GameDb) DoesSessionExist(sessionId int64) (isExists bool) {
 	return
 }
 
-func (database *GameDb) CreateSession(userId int64) (sessionId int64, previousSessionId int64, wasInSession bool) {
-	previousSessionId, wasInSession = database.LeaveSession(userId)
+// if isPreviousSessionAbandoned is true, the caller should end the previous session
+func (database *GameDb) CreateSession(userId int64) (sessionId int64, previousSessionId int64, wasInSession bool, isPreviousSessionAbandoned bool) {
+	previousSessionId, wasInSession, isPreviousSessionAbandoned = database.LeaveSession(userId)
 
 	database.mutex.Lock()
 	defer database.mutex.Unlock()
 
-	database.db.Exec("INSERT INTO sessions (token) VALUES (strftime('%s', 'now') || '-' || abs(random() % 100000))")
+	database.db.Exec("INSERT INTO sessions (token, last_activity_time) VALUES (strftime('%s', 'now') || '-' || abs(random() % 100000), strftime('%s', 'now'))")
 
 	sessionId = database.getLastInsertedItemId()
 
 	database.db.Exec(fmt.Sprintf("UPDATE OR ROLLBACK users SET current_session=%d WHERE id=%d", sessionId, userId))
+	database.db.Exec(fmt.Sprintf("UPDATE OR ROLLBACK sessions SET host_user_id=%d WHERE id=%d", userId, sessionId))
 
 	return
 }
 
-func (database *GameDb) ConnectToSession(userId int64, sessionId int64) (isSucceeded bool, previousSessionId int64, wasInSession bool) {
+// if isPreviousSessionAbandoned is true, the caller should end the previous session
+func (database *GameDb) ConnectToSession(userId int64, sessionId int64) (isSucceeded bool, previousSessionId int64, wasInSession bool, isPreviousSessionAbandoned bool) {
 	if !database.DoesSessionExist(sessionId) {
 		return
 	}
 
-	previousSessionId, wasInSession = database.LeaveSession(userId)
+	previousSessionId, wasInSession, isPreviousSessionAbandoned = database.LeaveSession(userId)
 
 	database.mutex.Lock()
 	defer database.mutex.Unlock()
 
 	database.db.Exec(fmt.Sprintf("UPDATE OR ROLLBACK users SET current_session=%d WHERE id=%d", sessionId, userId))
+	database.updateSessionActivityUnsafe(sessionId)
 
 	isSucceeded = true
 	return
@@ -569,7 +738,8 @@ func (database *GameDb) GetUsersInSession(sessionId int64) (users []int64) {
 	return
 }
 
-func (database *GameDb) LeaveSession(userId int64) (sessionId int64, wasInSession bool) {
+// if isSessionAbandoned is true, the caller should end the session, so the remaining players can be told why
+func (database *GameDb) LeaveSession(userId int64) (sessionId int64, wasInSession bool, isSessionAbandoned bool) {
 	sessionId, wasInSession = database.GetUserSession(userId)
 
 	if !wasInSession {
@@ -579,33 +749,30 @@ func (database *GameDb) LeaveSession(userId int64) (sessionId int64, wasInSessio
 	database.mutex.Lock()
 	defer database.mutex.Unlock()
 
-	database.db.Exec(fmt.Sprintf("UPDATE OR ROLLBACK users SET current_session=NULL, current_session_idle_count=0 WHERE id=%d", userId))
+	database.db.Exec(fmt.Sprintf("UPDATE OR ROLLBACK users SET current_session=NULL, current_session_idle_count=0, is_paused=0, current_session_team=0 WHERE id=%d", userId))
+	database.deleteUserPairConstraintsUnsafe(userId)
+	database.passHostToRemainingUserUnsafe(sessionId, userId)
 
-	// delete session if it doesn't have Telegram users in it
-	if database.getUsersCountInSessionUnsafe(sessionId, true) == 0 {
-		database.db.Exec(fmt.Sprintf("DELETE FROM session_commands WHERE session_id=%d", sessionId))
-		database.db.Exec(fmt.Sprintf("DELETE FROM recent_web_messages WHERE user_id in (select user_id from users where current_session=%d)", sessionId))
-		database.db.Exec(fmt.Sprintf("DELETE FROM sessions WHERE id=%d", sessionId))
-		database.db.Exec(fmt.Sprintf("DELETE FROM web_users WHERE user_id IN (SELECT id FROM users WHERE current_session=%d)", sessionId))
-		// the remaining users that have this session is the web users that we just deleted
-		database.db.Exec(fmt.Sprintf("DELETE FROM users WHERE current_session=%d", sessionId))
-	}
+	isSessionAbandoned = database.isSessionAbandonedUnsafe(sessionId)
 
 	return
 }
 
-func (database *GameDb) SetSessionMessageId(userId int64, messageId int64) {
-	database.mutex.Lock()
-	defer database.mutex.Unlock()
-
-	database.db.Exec(fmt.Sprintf("UPDATE OR ROLLBACK telegram_users SET current_session_message=%d WHERE user_id=%d", messageId, userId))
+// a session is abandoned if it doesn't have Telegram users in it, web hosted sessions are abandoned only when nobody is left
+func (database *GameDb) isSessionAbandonedUnsafe(sessionId int64) bool {
+	onlyTelegramUsers := !database.isSessionWebHostedUnsafe(sessionId)
+	return database.getUsersCountInSessionUnsafe(sessionId, onlyTelegramUsers) == 0
 }
 
-func (database *GameDb) GetSessionMessageId(userId int64) (messageId int64, isFound bool) {
+func (database *GameDb) IsSessionWebHosted(sessionId int64) (isWebHosted bool) {
 	database.mutex.Lock()
 	defer database.mutex.Unlock()
 
-	rows, err := database.db.Query(fmt.Sprintf("SELECT current_session_message FROM telegram_users WHERE user_id=%d AND current_session_message IS NOT NULL", userId))
+	return database.isSessionWebHostedUnsafe(sessionId)
+}
+
+func (database *GameDb) isSessionWebHostedUnsafe(sessionId int64) (isWebHosted bool) {
+	rows, err := database.db.Query(fmt.Sprintf("SELECT is_web_hosted FROM sessions WHERE id=%d", sessionId))
 	if err != nil {
 		log.Fatal(err.Error())
 	}
@@ -617,11 +784,10 @@ func (database *GameDb) GetSessionMessageId(userId int64) (messageId int64, isFo
 	}()
 
 	if rows.Next() {
-		err := rows.Scan(&messageId)
+		err := rows.Scan(&isWebHosted)
 		if err != nil {
 			log.Fatal(err.Error())
 		}
-		isFound = true
 	} else {
 		err = rows.Err()
 		if err != nil {
@@ -632,11 +798,56 @@ func (database *GameDb) GetSessionMessageId(userId int64) (messageId int64, isFo
 	return
 }
 
-func (database *GameDb) GetSessionIdFromToken(token string) (sessionId int64, isFound bool) {
+func (database *GameDb) EndSession(sessionId int64) {
 	database.mutex.Lock()
 	defer database.mutex.Unlock()
 
-	rows, err := database.db.Query(fmt.Sprintf("SELECT id FROM sessions WHERE token='%s' LIMIT 1", dbBase.SanitizeString(token)))
+	database.endSessionUnsafe(sessionId)
+}
+
+// removes the session with all its data, web users are deleted and Telegram users are moved out of the session
+func (database *GameDb) endSessionUnsafe(sessionId int64) {
+	database.db.Exec(fmt.Sprintf("DELETE FROM session_commands WHERE session_id=%d", sessionId))
+	database.db.Exec(fmt.Sprintf("DELETE FROM session_penalty_commands WHERE session_id=%d", sessionId))
+	database.db.Exec(fmt.Sprintf("DELETE FROM revealed_dare_players WHERE dare_id IN (SELECT id FROM revealed_dares WHERE session_id=%d)", sessionId))
+	database.db.Exec(fmt.Sprintf("DELETE FROM revealed_dares WHERE session_id=%d", sessionId))
+	database.db.Exec(fmt.Sprintf("DELETE FROM pair_constraints WHERE session_id=%d", sessionId))
+	database.db.Exec(fmt.Sprintf("DELETE FROM recent_web_messages WHERE user_id IN (SELECT user_id FROM web_users JOIN users ON users.id=web_users.user_id WHERE users.current_session=%d)", sessionId))
+	database.db.Exec(fmt.Sprintf("DELETE FROM web_users WHERE user_id IN (SELECT id FROM users WHERE current_session=%d)", sessionId))
+	// users in the session that are not Telegram users are the web users that we just deleted
+	database.db.Exec(fmt.Sprintf("DELETE FROM users WHERE current_session=%d AND id NOT IN (SELECT user_id FROM telegram_users)", sessionId))
+	database.db.Exec(fmt.Sprintf("UPDATE OR ROLLBACK users SET current_session=NULL, current_session_idle_count=0, is_paused=0, current_session_team=0 WHERE current_session=%d", sessionId))
+	database.db.Exec(fmt.Sprintf("DELETE FROM sessions WHERE id=%d", sessionId))
+}
+
+func (database *GameDb) updateSessionActivityUnsafe(sessionId int64) {
+	database.db.Exec(fmt.Sprintf("UPDATE OR ROLLBACK sessions SET last_activity_time=strftime('%%s', 'now') WHERE id=%d", sessionId))
+}
+
+func (database *GameDb) GetSessionsWithoutTelegramUsers() (sessions []int64) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	// web hosted sessions don't need Telegram users
+	return database.getSessionIdsUnsafe("SELECT id FROM sessions WHERE is_web_hosted=0 AND id NOT IN (SELECT users.current_session FROM users JOIN telegram_users ON users.id=telegram_users.user_id WHERE users.current_session IS NOT NULL)")
+}
+
+func (database *GameDb) GetSessionsInactiveSince(timestamp int64) (sessions []int64) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	return database.getSessionIdsUnsafe(fmt.Sprintf("SELECT id FROM sessions WHERE IFNULL(last_activity_time, 0)<%d", timestamp))
+}
+
+func (database *GameDb) GetWebHostedSessionsInactiveSince(timestamp int64) (sessions []int64) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	return database.getSessionIdsUnsafe(fmt.Sprintf("SELECT id FROM sessions WHERE is_web_hosted=1 AND IFNULL(last_activity_time, 0)<%d", timestamp))
+}
+
+func (database *GameDb) getSessionIdsUnsafe(request string) (sessions []int64) {
+	rows, err := database.db.Query(request)
 	if err != nil {
 		log.Fatal(err.Error())
 	}
@@ -647,28 +858,28 @@ func (database *GameDb) GetSessionIdFromToken(token string) (sessionId int64, is
 		}
 	}()
 
-	if rows.Next() {
+	for rows.Next() {
+		var sessionId int64
 		err := rows.Scan(&sessionId)
 		if err != nil {
 			log.Fatal(err.Error())
-		} else {
-			isFound = true
-		}
-	} else {
-		err = rows.Err()
-		if err != nil {
-			log.Fatal(err)
 		}
+		sessions = append(sessions, sessionId)
 	}
 
 	return
 }
 
-func (database *GameDb) GetTokenFromSessionId(sessionId int64) (token string, isFound bool) {
+// if the user was the host, give the host role to someone who is still in the session (Telegram users first)
+func (database *GameDb) passHostToRemainingUserUnsafe(sessionId int64, leftUserId int64) {
+	database.db.Exec(fmt.Sprintf("UPDATE OR ROLLBACK sessions SET host_user_id=(SELECT users.id FROM users LEFT JOIN telegram_users ON users.id=telegram_users.user_id WHERE users.current_session=%d ORDER BY telegram_users.user_id IS NULL, users.id LIMIT 1) WHERE id=%d AND host_user_id=%d", sessionId, sessionId, leftUserId))
+}
+
+func (database *GameDb) GetSessionHost(sessionId int64) (hostUserId int64, isFound bool) {
 	database.mutex.Lock()
 	defer database.mutex.Unlock()
 
-	rows, err := database.db.Query(fmt.Sprintf("SELECT token FROM sessions WHERE id=%d LIMIT 1", sessionId))
+	rows, err := database.db.Query(fmt.Sprintf("SELECT host_user_id FROM sessions WHERE id=%d AND host_user_id IS NOT NULL", sessionId))
 	if err != nil {
 		log.Fatal(err.Error())
 	}
@@ -680,7 +891,7 @@ func (database *GameDb) GetTokenFromSessionId(sessionId int64) (token string, is
 	}()
 
 	if rows.Next() {
-		err := rows.Scan(&token)
+		err := rows.Scan(&hostUserId)
 		if err != nil {
 			log.Fatal(err.Error())
 		} else {
@@ -696,55 +907,59 @@ func (database *GameDb) GetTokenFromSessionId(sessionId int64) (token string, is
 	return
 }
 
-func (database *GameDb) AddSessionSuggestedCommand(sessionId int64, command string) {
+func (database *GameDb) SetSessionHost(sessionId int64, hostUserId int64) {
 	database.mutex.Lock()
 	defer database.mutex.Unlock()
 
-	database.db.Exec(fmt.Sprintf("INSERT INTO session_commands (session_id, command) VALUES (%d, '%s')", sessionId, dbBase.SanitizeString(command)))
+	database.db.Exec(fmt.Sprintf("UPDATE OR ROLLBACK sessions SET host_user_id=%d WHERE id=%d", hostUserId, sessionId))
 }
 
-func (database *GameDb) PopRandomSessionSuggestedCommand(sessionId int64) (command string, isSucceeded bool) {
+func (database *GameDb) GetSessionPlaceholders(sessionId int64) (placeholders string) {
 	database.mutex.Lock()
 	defer database.mutex.Unlock()
 
-	rows, err := database.db.Query(fmt.Sprintf("SELECT id, command FROM session_commands WHERE session_id=%d ORDER BY RANDOM() LIMIT 1", sessionId))
+	rows, err := database.db.Query(fmt.Sprintf("SELECT IFNULL(placeholders, '') FROM sessions WHERE id=%d", sessionId))
 	if err != nil {
 		log.Fatal(err.Error())
 	}
-
-	if rows.Next() {
-		var rowId int64
-		err := rows.Scan(&rowId, &command)
+	defer func() {
+		err := rows.Close()
 		if err != nil {
 			log.Fatal(err.Error())
 		}
-		err = rows.Close()
+	}()
+
+	if rows.Next() {
+		err := rows.Scan(&placeholders)
 		if err != nil {
 			log.Fatal(err.Error())
 		}
-
-		database.db.Exec(fmt.Sprintf("DELETE FROM session_commands WHERE id=%d", rowId))
-
-		isSucceeded = true
 	} else {
 		err = rows.Err()
 		if err != nil {
 			log.Fatal(err)
 		}
-		err := rows.Close()
-		if err != nil {
-			log.Fatal(err.Error())
-		}
 	}
 
 	return
 }
 
-func (database *GameDb) GetSessionSuggestedCommandCount(sessionId int64) (commandsCount int64) {
+func (database *GameDb) SetSessionPlaceholders(sessionId int64, placeholders string) {
 	database.mutex.Lock()
 	defer database.mutex.Unlock()
 
-	rows, err := database.db.Query(fmt.Sprintf("SELECT COUNT(*) FROM session_commands WHERE session_id=%d", sessionId))
+	if len(placeholders) > 0 {
+		database.db.Exec(fmt.Sprintf("UPDATE OR ROLLBACK sessions SET placeholders='%s' WHERE id=%d", dbBase.SanitizeString(placeholders), sessionId))
+	} else {
+		database.db.Exec(fmt.Sprintf("UPDATE OR ROLLBACK sessions SET placeholders=NULL WHERE id=%d", sessionId))
+	}
+}
+
+func (database *GameDb) IsSessionInTruthOrDareMode(sessionId int64) (isTruthOrDare bool) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	rows, err := database.db.Query(fmt.Sprintf("SELECT truth_or_dare_mode FROM sessions WHERE id=%d", sessionId))
 	if err != nil {
 		log.Fatal(err.Error())
 	}
@@ -756,7 +971,7 @@ func (database *GameDb) GetSessionSuggestedCommandCount(sessionId int64) (comman
 	}()
 
 	if rows.Next() {
-		err := rows.Scan(&commandsCount)
+		err := rows.Scan(&isTruthOrDare)
 		if err != nil {
 			log.Fatal(err.Error())
 		}
@@ -770,23 +985,18 @@ func (database *GameDb) GetSessionSuggestedCommandCount(sessionId int64) (comman
 	return
 }
 
-type SessionUserInfo struct {
-	UserId                  int64
-	ChatId                  int64 // token for web users
-	Name                    string
-	Gender                  int
-	CurrentSessionIdleCount int
-	IsWebUser               bool
+func (database *GameDb) SetSessionTruthOrDareMode(sessionId int64, isTruthOrDare bool) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	database.db.Exec(fmt.Sprintf("UPDATE OR ROLLBACK sessions SET truth_or_dare_mode=%t WHERE id=%d", isTruthOrDare, sessionId))
 }
 
-func (database *GameDb) GetUsersInSessionInfo(sessionId int64) (users []SessionUserInfo) {
+func (database *GameDb) GetSessionKingMode(sessionId int64) (kingMode int) {
 	database.mutex.Lock()
 	defer database.mutex.Unlock()
 
-	// join users, telegram_users and web_users tables to get chat id as either chat id or token
-	request := fmt.Sprintf("SELECT users.id, IFNULL(telegram_users.chat_id, web_users.token) AS chat_id, users.name, users.gender, users.current_session_idle_count, IFNULL(web_users.token, 0) AS is_web_user FROM users LEFT JOIN telegram_users ON users.id=telegram_users.user_id LEFT JOIN web_users ON users.id=web_users.user_id WHERE users.current_session=%d", sessionId)
-
-	rows, err := database.db.Query(request)
+	rows, err := database.db.Query(fmt.Sprintf("SELECT king_mode FROM sessions WHERE id=%d", sessionId))
 	if err != nil {
 		log.Fatal(err.Error())
 	}
@@ -797,36 +1007,34 @@ func (database *GameDb) GetUsersInSessionInfo(sessionId int64) (users []SessionU
 		}
 	}()
 
-	for rows.Next() {
-		var userInfo SessionUserInfo
-		var isWebUser int
-		err := rows.Scan(&userInfo.UserId, &userInfo.ChatId, &userInfo.Name, &userInfo.Gender, &userInfo.CurrentSessionIdleCount, &isWebUser)
+	if rows.Next() {
+		err := rows.Scan(&kingMode)
 		if err != nil {
 			log.Fatal(err.Error())
 		}
-		userInfo.IsWebUser = isWebUser != 0
-		users = append(users, userInfo)
+	} else {
+		err = rows.Err()
+		if err != nil {
+			log.Fatal(err)
+		}
 	}
 
 	return
 }
 
-func (database *GameDb) UpdateUsersIdleCount(usersToIncrease []int64, countIncrease int, usersToReset []int64) {
+func (database *GameDb) SetSessionKingMode(sessionId int64, kingMode int) {
 	database.mutex.Lock()
 	defer database.mutex.Unlock()
 
-	usersToIncreaseIds := strings.Trim(strings.Join(strings.Fields(fmt.Sprint(usersToIncrease)), ","), "[]")
-	usersToResetIds := strings.Trim(strings.Join(strings.Fields(fmt.Sprint(usersToReset)), ","), "[]")
-
-	database.db.Exec(fmt.Sprintf("UPDATE OR ROLLBACK users SET current_session_idle_count=current_session_idle_count+'%d' WHERE id IN (%s)", countIncrease, usersToIncreaseIds))
-	database.db.Exec(fmt.Sprintf("UPDATE OR ROLLBACK users SET current_session_idle_count='0' WHERE id IN (%s)", usersToResetIds))
+	database.db.Exec(fmt.Sprintf("UPDATE OR ROLLBACK sessions SET king_mode=%d WHERE id=%d", kingMode, sessionId))
 }
 
-func (database *GameDb) AddWebUser(sessionId int64, token int64, name string, gender int) (wasAdded bool) {
+// returns the last king of the session, who can already be not in the session
+func (database *GameDb) GetSessionKing(sessionId int64) (kingUserId int64, isInSession bool) {
 	database.mutex.Lock()
 	defer database.mutex.Unlock()
 
-	rows, err := database.db.Query(fmt.Sprintf("SELECT 1 FROM web_users WHERE token=%d", token))
+	rows, err := database.db.Query(fmt.Sprintf("SELECT IFNULL(king_user_id, 0), EXISTS(SELECT 1 FROM users WHERE users.id=sessions.king_user_id AND users.current_session=sessions.id) FROM sessions WHERE id=%d", sessionId))
 	if err != nil {
 		log.Fatal(err.Error())
 	}
@@ -838,31 +1046,34 @@ func (database *GameDb) AddWebUser(sessionId int64, token int64, name string, ge
 	}()
 
 	if rows.Next() {
-		return false
-	}
-
-	err = rows.Close()
-	if err != nil {
-		log.Fatal(err.Error())
+		err := rows.Scan(&kingUserId, &isInSession)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+	} else {
+		err = rows.Err()
+		if err != nil {
+			log.Fatal(err)
+		}
 	}
 
-	database.db.Exec(fmt.Sprintf("INSERT INTO users (name, gender, current_session, current_session_idle_count) VALUES ('%s', %d, %d, 0)", dbBase.SanitizeString(name), gender, sessionId))
-
-	userId := database.getLastInsertedItemId()
+	return
+}
 
-	database.db.Exec(fmt.Sprintf("INSERT INTO web_users (user_id, token) VALUES (%d, %d)", userId, token))
+func (database *GameDb) SetSessionKing(sessionId int64, kingUserId int64) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
 
-	return true
+	database.db.Exec(fmt.Sprintf("UPDATE OR ROLLBACK sessions SET king_user_id=%d WHERE id=%d", kingUserId, sessionId))
 }
 
-func (database *GameDb) RemoveWebUser(token int64) {
+func (database *GameDb) GetSessionSelectionPolicy(sessionId int64) (policy int) {
 	database.mutex.Lock()
 	defer database.mutex.Unlock()
 
-	rows, err := database.db.Query(fmt.Sprintf("SELECT user_id FROM web_users WHERE token=%d", token))
+	rows, err := database.db.Query(fmt.Sprintf("SELECT selection_policy FROM sessions WHERE id=%d", sessionId))
 	if err != nil {
 		log.Fatal(err.Error())
-		return
 	}
 	defer func() {
 		err := rows.Close()
@@ -871,52 +1082,51 @@ func (database *GameDb) RemoveWebUser(token int64) {
 		}
 	}()
 
-	var userId int64
 	if rows.Next() {
-		err := rows.Scan(&userId)
+		err := rows.Scan(&policy)
 		if err != nil {
 			log.Fatal(err.Error())
 		}
 	} else {
-		return
-	}
-
-	err = rows.Close()
-	if err != nil {
-		log.Fatal(err.Error())
-		return
+		err = rows.Err()
+		if err != nil {
+			log.Fatal(err)
+		}
 	}
 
-	database.db.Exec(fmt.Sprintf("DELETE FROM web_users WHERE token=%d", token))
-	database.db.Exec(fmt.Sprintf("DELETE FROM users WHERE id=%d", userId))
-	database.db.Exec(fmt.Sprintf("DELETE FROM recent_web_messages WHERE user_id=%d", userId))
+	return
 }
 
-func (database *GameDb) DoesWebUserExist(token int64) (isExists bool) {
+func (database *GameDb) SetSessionSelectionPolicy(sessionId int64, policy int) {
 	database.mutex.Lock()
 	defer database.mutex.Unlock()
 
-	rows, err := database.db.Query(fmt.Sprintf("SELECT 1 FROM web_users WHERE token=%d", token))
-	if err != nil {
-		log.Fatal(err.Error())
-	}
-	defer func() {
-		err := rows.Close()
-		if err != nil {
-			log.Fatal(err.Error())
-		}
-	}()
+	database.db.Exec(fmt.Sprintf("UPDATE OR ROLLBACK sessions SET selection_policy=%d WHERE id=%d", policy, sessionId))
+}
 
-	isExists = rows.Next()
+type PairConstraintInfo struct {
+	UserId        int64
+	PartnerUserId int64
+	Kind          int
+}
 
-	return
+// PairConstraintNone removes the rule the player chose for the partner
+func (database *GameDb) SetPairConstraint(sessionId int64, userId int64, partnerUserId int64, kind int) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	database.db.Exec(fmt.Sprintf("DELETE FROM pair_constraints WHERE session_id=%d AND user_id=%d AND partner_user_id=%d", sessionId, userId, partnerUserId))
+	if kind != PairConstraintNone {
+		database.db.Exec(fmt.Sprintf("INSERT INTO pair_constraints (session_id, user_id, partner_user_id, kind) VALUES (%d, %d, %d, %d)", sessionId, userId, partnerUserId, kind))
+	}
 }
 
-func (database *GameDb) GetWebUserId(token int64) (userId int64, isFound bool) {
+// returns the rules chosen by the players of the session, including the ones their partners didn't agree to
+func (database *GameDb) GetSessionPairConstraints(sessionId int64) (constraints []PairConstraintInfo) {
 	database.mutex.Lock()
 	defer database.mutex.Unlock()
 
-	rows, err := database.db.Query(fmt.Sprintf("SELECT user_id FROM web_users WHERE token=%d", token))
+	rows, err := database.db.Query(fmt.Sprintf("SELECT user_id, partner_user_id, kind FROM pair_constraints WHERE session_id=%d ORDER BY id", sessionId))
 	if err != nil {
 		log.Fatal(err.Error())
 	}
@@ -927,14 +1137,644 @@ func (database *GameDb) GetWebUserId(token int64) (userId int64, isFound bool) {
 		}
 	}()
 
-	if rows.Next() {
-		err := rows.Scan(&userId)
+	for rows.Next() {
+		var constraint PairConstraintInfo
+		err := rows.Scan(&constraint.UserId, &constraint.PartnerUserId, &constraint.Kind)
 		if err != nil {
 			log.Fatal(err.Error())
 		}
-		isFound = true
-	} else {
-		err = rows.Err()
+		constraints = append(constraints, constraint)
+	}
+
+	return
+}
+
+func (database *GameDb) deleteUserPairConstraintsUnsafe(userId int64) {
+	database.db.Exec(fmt.Sprintf("DELETE FROM pair_constraints WHERE user_id=%d OR partner_user_id=%d", userId, userId))
+}
+
+// the players of the first team go first, the players of the session who are not listed are left without a team
+// empty list removes the teams
+func (database *GameDb) SetSessionTeams(sessionId int64, teams [][]int64) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	database.db.Exec(fmt.Sprintf("UPDATE OR ROLLBACK sessions SET teams_count=%d WHERE id=%d", len(teams), sessionId))
+	database.db.Exec(fmt.Sprintf("UPDATE OR ROLLBACK users SET current_session_team=0 WHERE current_session=%d", sessionId))
+	for i, team := range teams {
+		if len(team) == 0 {
+			continue
+		}
+		teamIds := strings.Trim(strings.Join(strings.Fields(fmt.Sprint(team)), ","), "[]")
+		database.db.Exec(fmt.Sprintf("UPDATE OR ROLLBACK users SET current_session_team=%d WHERE current_session=%d AND id IN (%s)", i+1, sessionId, teamIds))
+	}
+}
+
+func (database *GameDb) GetSessionTeamsCount(sessionId int64) (teamsCount int) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	rows, err := database.db.Query(fmt.Sprintf("SELECT teams_count FROM sessions WHERE id=%d", sessionId))
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+	}()
+
+	if rows.Next() {
+		err := rows.Scan(&teamsCount)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+	} else {
+		err = rows.Err()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	return
+}
+
+func (database *GameDb) IsUserSessionHost(userId int64) (isHost bool) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	rows, err := database.db.Query(fmt.Sprintf("SELECT 1 FROM sessions JOIN users ON users.current_session=sessions.id WHERE users.id=%d AND sessions.host_user_id=%d LIMIT 1", userId, userId))
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+	}()
+
+	isHost = rows.Next()
+
+	return
+}
+
+func (database *GameDb) SetSessionMessageId(userId int64, messageId int64) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	database.db.Exec(fmt.Sprintf("UPDATE OR ROLLBACK telegram_users SET current_session_message=%d WHERE user_id=%d", messageId, userId))
+}
+
+func (database *GameDb) GetSessionMessageId(userId int64) (messageId int64, isFound bool) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	rows, err := database.db.Query(fmt.Sprintf("SELECT current_session_message FROM telegram_users WHERE user_id=%d AND current_session_message IS NOT NULL", userId))
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+	}()
+
+	if rows.Next() {
+		err := rows.Scan(&messageId)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		isFound = true
+	} else {
+		err = rows.Err()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	return
+}
+
+func (database *GameDb) GetSessionIdFromToken(token string) (sessionId int64, isFound bool) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	rows, err := database.db.Query(fmt.Sprintf("SELECT id FROM sessions WHERE token='%s' LIMIT 1", dbBase.SanitizeString(token)))
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+	}()
+
+	if rows.Next() {
+		err := rows.Scan(&sessionId)
+		if err != nil {
+			log.Fatal(err.Error())
+		} else {
+			isFound = true
+		}
+	} else {
+		err = rows.Err()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	return
+}
+
+func (database *GameDb) GetTokenFromSessionId(sessionId int64) (token string, isFound bool) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	rows, err := database.db.Query(fmt.Sprintf("SELECT token FROM sessions WHERE id=%d LIMIT 1", sessionId))
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+	}()
+
+	if rows.Next() {
+		err := rows.Scan(&token)
+		if err != nil {
+			log.Fatal(err.Error())
+		} else {
+			isFound = true
+		}
+	} else {
+		err = rows.Err()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	return
+}
+
+func getCategoryCondition(category int) string {
+	if category == AnyCategory {
+		return ""
+	}
+	return fmt.Sprintf(" AND category=%d", category)
+}
+
+func (database *GameDb) AddSessionSuggestedCommand(sessionId int64, command string) {
+	database.AddSessionSuggestedCommandInCategory(sessionId, command, DareCategory)
+}
+
+func (database *GameDb) AddSessionSuggestedCommandInCategory(sessionId int64, command string, category int) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	database.db.Exec(fmt.Sprintf("INSERT INTO session_commands (session_id, command, category) VALUES (%d, '%s', %d)", sessionId, dbBase.SanitizeString(command), category))
+	database.updateSessionActivityUnsafe(sessionId)
+}
+
+func (database *GameDb) PopRandomSessionSuggestedCommand(sessionId int64) (command string, isSucceeded bool) {
+	return database.PopRandomMatchingSessionSuggestedCommand(sessionId, AnyCategory, func(string) bool { return true })
+}
+
+// isMatching is called with the database locked, so it should not access the database
+func (database *GameDb) PopRandomMatchingSessionSuggestedCommand(sessionId int64, category int, isMatching func(command string) bool) (command string, isSucceeded bool) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	rows, err := database.db.Query(fmt.Sprintf("SELECT id, command FROM session_commands WHERE session_id=%d%s ORDER BY RANDOM()", sessionId, getCategoryCondition(category)))
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	var rowId int64
+	for rows.Next() {
+		err := rows.Scan(&rowId, &command)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+
+		if isMatching(command) {
+			isSucceeded = true
+			break
+		}
+	}
+
+	err = rows.Err()
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = rows.Close()
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	if isSucceeded {
+		database.db.Exec(fmt.Sprintf("DELETE FROM session_commands WHERE id=%d", rowId))
+		database.updateSessionActivityUnsafe(sessionId)
+	} else {
+		command = ""
+	}
+
+	return
+}
+
+func (database *GameDb) AddSessionSuggestedCommands(sessionId int64, commands []CategorizedCommand) {
+	if len(commands) == 0 {
+		return
+	}
+
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	values := make([]string, 0, len(commands))
+	for _, command := range commands {
+		values = append(values, fmt.Sprintf("(%d, '%s', %d)", sessionId, dbBase.SanitizeString(command.Command), command.Category))
+	}
+
+	database.db.Exec("INSERT INTO session_commands (session_id, command, category) VALUES " + strings.Join(values, ","))
+	database.updateSessionActivityUnsafe(sessionId)
+}
+
+func (database *GameDb) GetSessionSuggestedCommands(sessionId int64) (commands []CategorizedCommand) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	rows, err := database.db.Query(fmt.Sprintf("SELECT command, category FROM session_commands WHERE session_id=%d ORDER BY id", sessionId))
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+	}()
+
+	for rows.Next() {
+		var command CategorizedCommand
+		err := rows.Scan(&command.Command, &command.Category)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		commands = append(commands, command)
+	}
+
+	return
+}
+
+func (database *GameDb) GetSessionSuggestedCommandCount(sessionId int64) (commandsCount int64) {
+	return database.GetSessionSuggestedCommandCountInCategory(sessionId, AnyCategory)
+}
+
+func (database *GameDb) GetSessionSuggestedCommandCountInCategory(sessionId int64, category int) (commandsCount int64) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	rows, err := database.db.Query(fmt.Sprintf("SELECT COUNT(*) FROM session_commands WHERE session_id=%d%s", sessionId, getCategoryCondition(category)))
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+	}()
+
+	if rows.Next() {
+		err := rows.Scan(&commandsCount)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+	} else {
+		err = rows.Err()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	return
+}
+
+func (database *GameDb) AddSessionPenaltyCommand(sessionId int64, command string) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	database.db.Exec(fmt.Sprintf("INSERT INTO session_penalty_commands (session_id, command) VALUES (%d, '%s')", sessionId, dbBase.SanitizeString(command)))
+	database.updateSessionActivityUnsafe(sessionId)
+}
+
+// penalties stay in the pool after they are drawn
+// isMatching is called with the database locked, so it should not access the database
+func (database *GameDb) GetRandomMatchingSessionPenaltyCommand(sessionId int64, isMatching func(command string) bool) (command string, isFound bool) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	rows, err := database.db.Query(fmt.Sprintf("SELECT command FROM session_penalty_commands WHERE session_id=%d ORDER BY RANDOM()", sessionId))
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+	}()
+
+	for rows.Next() {
+		err := rows.Scan(&command)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+
+		if isMatching(command) {
+			return command, true
+		}
+	}
+
+	err = rows.Err()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return "", false
+}
+
+func (database *GameDb) GetSessionPenaltyCommandCount(sessionId int64) (commandsCount int64) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	rows, err := database.db.Query(fmt.Sprintf("SELECT COUNT(*) FROM session_penalty_commands WHERE session_id=%d", sessionId))
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+	}()
+
+	if rows.Next() {
+		err := rows.Scan(&commandsCount)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+	} else {
+		err = rows.Err()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	return
+}
+
+type SessionUserInfo struct {
+	UserId                  int64
+	ChatId                  int64 // token for web users
+	Name                    string
+	Groups                  []string
+	CurrentSessionIdleCount int
+	IsWebUser               bool
+	IsPaused                bool
+	DeclinedTags            []string
+	Team                    int // starts from 1, zero if the player is not in a team
+}
+
+func (database *GameDb) GetUsersInSessionInfo(sessionId int64) (users []SessionUserInfo) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	// join users, telegram_users and web_users tables to get chat id as either chat id or token
+	request := fmt.Sprintf("SELECT users.id, IFNULL(telegram_users.chat_id, web_users.token) AS chat_id, users.name, users.group_keys, users.current_session_idle_count, IFNULL(web_users.token, 0) AS is_web_user, users.is_paused, users.declined_tags, users.current_session_team FROM users LEFT JOIN telegram_users ON users.id=telegram_users.user_id LEFT JOIN web_users ON users.id=web_users.user_id WHERE users.current_session=%d", sessionId)
+
+	rows, err := database.db.Query(request)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+	}()
+
+	for rows.Next() {
+		var userInfo SessionUserInfo
+		var isWebUser int
+		var groups string
+		var declinedTags string
+		err := rows.Scan(&userInfo.UserId, &userInfo.ChatId, &userInfo.Name, &groups, &userInfo.CurrentSessionIdleCount, &isWebUser, &userInfo.IsPaused, &declinedTags, &userInfo.Team)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		userInfo.IsWebUser = isWebUser != 0
+		userInfo.Groups = splitList(groups)
+		userInfo.DeclinedTags = splitList(declinedTags)
+		users = append(users, userInfo)
+	}
+
+	return
+}
+
+func (database *GameDb) IsUserPaused(userId int64) (isPaused bool) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	rows, err := database.db.Query(fmt.Sprintf("SELECT is_paused FROM users WHERE id=%d", userId))
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+	}()
+
+	if rows.Next() {
+		err := rows.Scan(&isPaused)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+	} else {
+		err = rows.Err()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	return
+}
+
+// paused players stay in the session but are not drawn for dares
+func (database *GameDb) SetUserPaused(userId int64, isPaused bool) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	database.db.Exec(fmt.Sprintf("UPDATE OR ROLLBACK users SET is_paused=%t WHERE id=%d", isPaused, userId))
+}
+
+func (database *GameDb) UpdateUsersIdleCount(usersToIncrease []int64, countIncrease int, usersToReset []int64) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	usersToIncreaseIds := strings.Trim(strings.Join(strings.Fields(fmt.Sprint(usersToIncrease)), ","), "[]")
+	usersToResetIds := strings.Trim(strings.Join(strings.Fields(fmt.Sprint(usersToReset)), ","), "[]")
+
+	database.db.Exec(fmt.Sprintf("UPDATE OR ROLLBACK users SET current_session_idle_count=current_session_idle_count+'%d' WHERE id IN (%s)", countIncrease, usersToIncreaseIds))
+	database.db.Exec(fmt.Sprintf("UPDATE OR ROLLBACK users SET current_session_idle_count='0' WHERE id IN (%s)", usersToResetIds))
+}
+
+func (database *GameDb) AddWebUser(sessionId int64, token int64, name string, groups []string) (wasAdded bool) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	_, wasAdded = database.addWebUserUnsafe(sessionId, token, name, groups)
+	return
+}
+
+// creates a session without Telegram users, the new web user becomes its host
+func (database *GameDb) CreateWebSession(token int64, name string, groups []string) (sessionId int64, wasAdded bool) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	if database.doesWebUserExistUnsafe(token) {
+		return
+	}
+
+	database.db.Exec("INSERT INTO sessions (token, last_activity_time, is_web_hosted) VALUES (strftime('%s', 'now') || '-' || abs(random() % 100000), strftime('%s', 'now'), 1)")
+
+	sessionId = database.getLastInsertedItemId()
+
+	userId, _ := database.addWebUserUnsafe(sessionId, token, name, groups)
+	database.db.Exec(fmt.Sprintf("UPDATE OR ROLLBACK sessions SET host_user_id=%d WHERE id=%d", userId, sessionId))
+
+	wasAdded = true
+	return
+}
+
+func (database *GameDb) addWebUserUnsafe(sessionId int64, token int64, name string, groups []string) (userId int64, wasAdded bool) {
+	if database.doesWebUserExistUnsafe(token) {
+		return
+	}
+
+	database.db.Exec(fmt.Sprintf("INSERT INTO users (name, group_keys, current_session, current_session_idle_count) VALUES ('%s', '%s', %d, 0)", dbBase.SanitizeString(name), dbBase.SanitizeString(strings.Join(groups, ",")), sessionId))
+
+	userId = database.getLastInsertedItemId()
+
+	database.db.Exec(fmt.Sprintf("INSERT INTO web_users (user_id, token) VALUES (%d, %d)", userId, token))
+	database.updateSessionActivityUnsafe(sessionId)
+
+	wasAdded = true
+	return
+}
+
+func (database *GameDb) doesWebUserExistUnsafe(token int64) (isExists bool) {
+	rows, err := database.db.Query(fmt.Sprintf("SELECT 1 FROM web_users WHERE token=%d", token))
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+	}()
+
+	isExists = rows.Next()
+
+	return
+}
+
+// if isSessionAbandoned is true, the caller should end the session, so the remaining players can be told why
+func (database *GameDb) RemoveWebUser(token int64) (isSessionAbandoned bool) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	rows, err := database.db.Query(fmt.Sprintf("SELECT web_users.user_id, IFNULL(users.current_session, 0) FROM web_users JOIN users ON users.id=web_users.user_id WHERE web_users.token=%d", token))
+	if err != nil {
+		log.Fatal(err.Error())
+		return
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+	}()
+
+	var userId int64
+	var sessionId int64
+	if rows.Next() {
+		err := rows.Scan(&userId, &sessionId)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+	} else {
+		return
+	}
+
+	err = rows.Close()
+	if err != nil {
+		log.Fatal(err.Error())
+		return
+	}
+
+	database.db.Exec(fmt.Sprintf("DELETE FROM web_users WHERE token=%d", token))
+	database.db.Exec(fmt.Sprintf("DELETE FROM users WHERE id=%d", userId))
+	database.db.Exec(fmt.Sprintf("DELETE FROM recent_web_messages WHERE user_id=%d", userId))
+	// the id can be reused by a new user, who shouldn't get the score of this one
+	database.db.Exec(fmt.Sprintf("DELETE FROM revealed_dare_players WHERE user_id=%d", userId))
+	database.deleteUserPairConstraintsUnsafe(userId)
+	database.passHostToRemainingUserUnsafe(sessionId, userId)
+
+	if sessionId != 0 {
+		isSessionAbandoned = database.isSessionAbandonedUnsafe(sessionId)
+	}
+	return
+}
+
+func (database *GameDb) DoesWebUserExist(token int64) (isExists bool) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	return database.doesWebUserExistUnsafe(token)
+}
+
+func (database *GameDb) GetWebUserId(token int64) (userId int64, isFound bool) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	rows, err := database.db.Query(fmt.Sprintf("SELECT user_id FROM web_users WHERE token=%d", token))
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+	}()
+
+	if rows.Next() {
+		err := rows.Scan(&userId)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		isFound = true
+	} else {
+		err = rows.Err()
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -979,3 +1819,344 @@ func (database *GameDb) GetNewRecentWebMessages(userId int64, lastIndex int) (co
 
 	return
 }
+
+type DarePackInfo struct {
+	Id            int64
+	Name          string
+	Language      string
+	CommandsCount int64
+}
+
+// saves not revealed dares of the session as a pack, a pack of the user with the same name gets replaced
+func (database *GameDb) SaveSessionCommandsAsPack(sessionId int64, userId int64, name string) (packId int64) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	database.deleteDarePackUnsafe(fmt.Sprintf("owner_user_id=%d AND name='%s'", userId, dbBase.SanitizeString(name)))
+
+	database.db.Exec(fmt.Sprintf("INSERT INTO dare_packs (owner_user_id, name) VALUES (%d, '%s')", userId, dbBase.SanitizeString(name)))
+
+	packId = database.getLastInsertedItemId()
+
+	database.db.Exec(fmt.Sprintf("INSERT INTO dare_pack_commands (pack_id, command, category) SELECT %d, command, category FROM session_commands WHERE session_id=%d", packId, sessionId))
+
+	return
+}
+
+// creates a pack from the given dares, a pack of the user with the same name gets replaced
+func (database *GameDb) CreateDarePack(userId int64, name string, language string, commands []CategorizedCommand) (packId int64) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	database.deleteDarePackUnsafe(fmt.Sprintf("owner_user_id=%d AND name='%s'", userId, dbBase.SanitizeString(name)))
+
+	database.db.Exec(fmt.Sprintf("INSERT INTO dare_packs (owner_user_id, name, language) VALUES (%d, '%s', '%s')", userId, dbBase.SanitizeString(name), dbBase.SanitizeString(language)))
+
+	packId = database.getLastInsertedItemId()
+
+	if len(commands) > 0 {
+		values := make([]string, 0, len(commands))
+		for _, command := range commands {
+			values = append(values, fmt.Sprintf("(%d, '%s', %d)", packId, dbBase.SanitizeString(command.Command), command.Category))
+		}
+		database.db.Exec("INSERT INTO dare_pack_commands (pack_id, command, category) VALUES " + strings.Join(values, ","))
+	}
+
+	return
+}
+
+func (database *GameDb) GetUserDarePacks(userId int64) (packs []DarePackInfo) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	rows, err := database.db.Query(fmt.Sprintf("SELECT dare_packs.id, dare_packs.name, dare_packs.language, COUNT(dare_pack_commands.id) FROM dare_packs LEFT JOIN dare_pack_commands ON dare_packs.id=dare_pack_commands.pack_id WHERE dare_packs.owner_user_id=%d GROUP BY dare_packs.id ORDER BY dare_packs.name", userId))
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+	}()
+
+	for rows.Next() {
+		var pack DarePackInfo
+		err := rows.Scan(&pack.Id, &pack.Name, &pack.Language, &pack.CommandsCount)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		packs = append(packs, pack)
+	}
+
+	return
+}
+
+func (database *GameDb) GetDarePackCommands(packId int64, ownerUserId int64) (commands []CategorizedCommand, isFound bool) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	rows, err := database.db.Query(fmt.Sprintf("SELECT 1 FROM dare_packs WHERE id=%d AND owner_user_id=%d", packId, ownerUserId))
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	isFound = rows.Next()
+
+	err = rows.Close()
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	if !isFound {
+		return
+	}
+
+	rows, err = database.db.Query(fmt.Sprintf("SELECT command, category FROM dare_pack_commands WHERE pack_id=%d ORDER BY id", packId))
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+	}()
+
+	for rows.Next() {
+		var command CategorizedCommand
+		err := rows.Scan(&command.Command, &command.Category)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		commands = append(commands, command)
+	}
+
+	return
+}
+
+func (database *GameDb) DeleteDarePack(packId int64, ownerUserId int64) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	database.deleteDarePackUnsafe(fmt.Sprintf("id=%d AND owner_user_id=%d", packId, ownerUserId))
+}
+
+func (database *GameDb) deleteDarePackUnsafe(condition string) {
+	database.db.Exec(fmt.Sprintf("DELETE FROM dare_pack_commands WHERE pack_id IN (SELECT id FROM dare_packs WHERE %s)", condition))
+	database.db.Exec(fmt.Sprintf("DELETE FROM dare_packs WHERE %s", condition))
+}
+
+func (database *GameDb) GetSessionSkipPenalty(sessionId int64) (penaltyPoints int) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	rows, err := database.db.Query(fmt.Sprintf("SELECT skip_penalty FROM sessions WHERE id=%d", sessionId))
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+	}()
+
+	if rows.Next() {
+		err := rows.Scan(&penaltyPoints)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+	} else {
+		err = rows.Err()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	return
+}
+
+func (database *GameDb) SetSessionSkipPenalty(sessionId int64, penaltyPoints int) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	database.db.Exec(fmt.Sprintf("UPDATE OR ROLLBACK sessions SET skip_penalty=%d WHERE id=%d", penaltyPoints, sessionId))
+}
+
+type RevealedDarePlayerInfo struct {
+	UserId        int64
+	Name          string // empty if the player doesn't exist anymore
+	State         int
+	PenaltyPoints int
+}
+
+type RevealedDareInfo struct {
+	Id        int64
+	SessionId int64
+	Command   string
+	Players   []RevealedDarePlayerInfo
+}
+
+// records a revealed dare together with the players named in it, the players should do it or skip it
+func (database *GameDb) AddRevealedDare(sessionId int64, command string, userIds []int64) (dareId int64) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	database.db.Exec(fmt.Sprintf("INSERT INTO revealed_dares (session_id, command) VALUES (%d, '%s')", sessionId, dbBase.SanitizeString(command)))
+
+	dareId = database.getLastInsertedItemId()
+
+	if len(userIds) > 0 {
+		values := make([]string, 0, len(userIds))
+		for _, userId := range userIds {
+			values = append(values, fmt.Sprintf("(%d, %d)", dareId, userId))
+		}
+		database.db.Exec("INSERT INTO revealed_dare_players (dare_id, user_id) VALUES " + strings.Join(values, ","))
+	}
+
+	return
+}
+
+func (database *GameDb) GetRevealedDare(dareId int64) (dare RevealedDareInfo, isFound bool) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	rows, err := database.db.Query(fmt.Sprintf("SELECT id, session_id, command FROM revealed_dares WHERE id=%d", dareId))
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	if rows.Next() {
+		err := rows.Scan(&dare.Id, &dare.SessionId, &dare.Command)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		isFound = true
+	}
+
+	err = rows.Close()
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	if !isFound {
+		return
+	}
+
+	rows, err = database.db.Query(fmt.Sprintf("SELECT revealed_dare_players.user_id, IFNULL(users.name, ''), revealed_dare_players.state, revealed_dare_players.penalty_points FROM revealed_dare_players LEFT JOIN users ON users.id=revealed_dare_players.user_id WHERE revealed_dare_players.dare_id=%d ORDER BY revealed_dare_players.id", dareId))
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+	}()
+
+	for rows.Next() {
+		var player RevealedDarePlayerInfo
+		err := rows.Scan(&player.UserId, &player.Name, &player.State, &player.PenaltyPoints)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		dare.Players = append(dare.Players, player)
+	}
+
+	return
+}
+
+// returns the last revealed dare of the session that has players who didn't do or skip it yet
+func (database *GameDb) GetLastPendingRevealedDareId(sessionId int64) (dareId int64, isFound bool) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	rows, err := database.db.Query(fmt.Sprintf("SELECT revealed_dares.id FROM revealed_dares JOIN revealed_dare_players ON revealed_dares.id=revealed_dare_players.dare_id WHERE revealed_dares.session_id=%d AND revealed_dare_players.state=%d ORDER BY revealed_dares.id DESC LIMIT 1", sessionId, DareStatePending))
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+	}()
+
+	if rows.Next() {
+		err := rows.Scan(&dareId)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		isFound = true
+	}
+
+	return
+}
+
+// changes the state only if the player hasn't done or skipped the dare yet
+// a skip gives the player the penalty points that are set for the session
+func (database *GameDb) SetRevealedDarePlayerState(dareId int64, userId int64, state int) (isChanged bool) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	rows, err := database.db.Query(fmt.Sprintf("SELECT 1 FROM revealed_dare_players WHERE dare_id=%d AND user_id=%d AND state=%d", dareId, userId, DareStatePending))
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	isChanged = rows.Next()
+	err = rows.Close()
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	if !isChanged {
+		return
+	}
+
+	penaltyPointsRequest := "0"
+	if state == DareStateSkipped {
+		penaltyPointsRequest = fmt.Sprintf("(SELECT sessions.skip_penalty FROM sessions JOIN revealed_dares ON revealed_dares.session_id=sessions.id WHERE revealed_dares.id=%d)", dareId)
+	}
+
+	database.db.Exec(fmt.Sprintf("UPDATE OR ROLLBACK revealed_dare_players SET state=%d, penalty_points=IFNULL(%s, 0) WHERE dare_id=%d AND user_id=%d", state, penaltyPointsRequest, dareId, userId))
+	return
+}
+
+type PlayerScoreInfo struct {
+	UserId        int64
+	Name          string
+	DoneCount     int
+	SkippedCount  int
+	PenaltyPoints int
+}
+
+// returns the scores of the players who are in the session, the best players go first
+func (database *GameDb) GetSessionScores(sessionId int64) (scores []PlayerScoreInfo) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	rows, err := database.db.Query(fmt.Sprintf("SELECT users.id, users.name, IFNULL(SUM(players.state=%d), 0) AS done_count, IFNULL(SUM(players.state=%d), 0), IFNULL(SUM(players.penalty_points), 0) AS penalty_points"+
+		" FROM users LEFT JOIN revealed_dare_players AS players ON players.user_id=users.id AND players.dare_id IN (SELECT id FROM revealed_dares WHERE session_id=%d)"+
+		" WHERE users.current_session=%d GROUP BY users.id ORDER BY done_count DESC, penalty_points, users.id", DareStateDone, DareStateSkipped, sessionId, sessionId))
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+	}()
+
+	for rows.Next() {
+		var score PlayerScoreInfo
+		err := rows.Scan(&score.UserId, &score.Name, &score.DoneCount, &score.SkippedCount, &score.PenaltyPoints)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		scores = append(scores, score)
+	}
+
+	return
+}
@@ -1,9 +1,11 @@
 package database
 
 import (
+	"fmt"
 	"github.com/stretchr/testify/require"
 	"os"
 	"testing"
+	"time"
 )
 
 const (
@@ -201,7 +203,7 @@ func TestUserLanguage(t *testing.T) {
 	}
 }
 
-func TestUserGender(t *testing.T) {
+func TestUserGroups(t *testing.T) {
 	assert := require.New(t)
 	db := createDbAndConnect(t)
 	defer clearDb()
@@ -214,18 +216,24 @@ func TestUserGender(t *testing.T) {
 	userId1 := db.GetOrCreateTelegramUserId(123, "", "")
 	userId2 := db.GetOrCreateTelegramUserId(321, "", "")
 
-	db.SetUserGender(userId1, 1)
+	db.SetUserGroups(userId1, []string{"female"})
 
 	{
-		assert.Equal(1, db.GetUserGender(userId1))
-		assert.Equal(0, db.GetUserGender(userId2))
+		assert.Equal([]string{"female"}, db.GetUserGroups(userId1))
+		assert.Nil(db.GetUserGroups(userId2))
 	}
 
-	db.SetUserGender(userId2, 2)
+	db.SetUserGroups(userId2, []string{"male", "nonbinary"})
 
 	{
-		assert.Equal(1, db.GetUserGender(userId1))
-		assert.Equal(2, db.GetUserGender(userId2))
+		assert.Equal([]string{"female"}, db.GetUserGroups(userId1))
+		assert.Equal([]string{"male", "nonbinary"}, db.GetUserGroups(userId2))
+	}
+
+	db.SetUserGroups(userId1, nil)
+
+	{
+		assert.Nil(db.GetUserGroups(userId1))
 	}
 }
 
@@ -242,7 +250,7 @@ func TestUserSession(t *testing.T) {
 	userId1 := db.GetOrCreateTelegramUserId(123, "", "")
 	userId2 := db.GetOrCreateTelegramUserId(321, "", "")
 
-	sessionId, _, _ := db.CreateSession(userId1)
+	sessionId, _, _, _ := db.CreateSession(userId1)
 	assert.True(db.DoesSessionExist(sessionId))
 
 	{
@@ -282,8 +290,12 @@ func TestUserSession(t *testing.T) {
 		assert.Equal(int64(2), db.GetUsersCountInSession(sessionId, false))
 	}
 
-	db.LeaveSession(userId1)
-	assert.True(db.DoesSessionExist(sessionId))
+	{
+		_, wasInSession, isSessionAbandoned := db.LeaveSession(userId1)
+		assert.True(wasInSession)
+		assert.False(isSessionAbandoned)
+		assert.True(db.DoesSessionExist(sessionId))
+	}
 
 	{
 		_, isInSession1 := db.GetUserSession(userId1)
@@ -295,7 +307,14 @@ func TestUserSession(t *testing.T) {
 		assert.Equal(int64(1), db.GetUsersCountInSession(sessionId, false))
 	}
 
-	db.LeaveSession(userId2)
+	// the session is ended by the caller, so the remaining players can be told why
+	{
+		_, _, isSessionAbandoned := db.LeaveSession(userId2)
+		assert.True(isSessionAbandoned)
+		assert.True(db.DoesSessionExist(sessionId))
+	}
+
+	db.EndSession(sessionId)
 	assert.False(db.DoesSessionExist(sessionId))
 
 	{
@@ -308,6 +327,164 @@ func TestUserSession(t *testing.T) {
 	}
 }
 
+func TestSessionHost(t *testing.T) {
+	assert := require.New(t)
+	db := createDbAndConnect(t)
+	defer clearDb()
+	if db == nil {
+		t.Fail()
+		return
+	}
+	defer db.Disconnect()
+
+	userId1 := db.GetOrCreateTelegramUserId(123, "", "")
+	userId2 := db.GetOrCreateTelegramUserId(321, "", "")
+
+	sessionId, _, _, _ := db.CreateSession(userId1)
+	db.ConnectToSession(userId2, sessionId)
+
+	{
+		hostUserId, isFound := db.GetSessionHost(sessionId)
+		assert.True(isFound)
+		assert.Equal(userId1, hostUserId)
+		assert.True(db.IsUserSessionHost(userId1))
+		assert.False(db.IsUserSessionHost(userId2))
+	}
+
+	db.SetSessionHost(sessionId, userId2)
+
+	{
+		hostUserId, isFound := db.GetSessionHost(sessionId)
+		assert.True(isFound)
+		assert.Equal(userId2, hostUserId)
+		assert.False(db.IsUserSessionHost(userId1))
+		assert.True(db.IsUserSessionHost(userId2))
+	}
+
+	webUserToken := int64(10)
+	db.AddWebUser(sessionId, webUserToken, "web", []string{"female"})
+	webUserId, _ := db.GetWebUserId(webUserToken)
+
+	// the host role goes to the remaining Telegram user first
+	db.LeaveSession(userId2)
+
+	{
+		hostUserId, isFound := db.GetSessionHost(sessionId)
+		assert.True(isFound)
+		assert.Equal(userId1, hostUserId)
+		assert.False(db.IsUserSessionHost(userId2))
+	}
+
+	db.SetSessionHost(sessionId, webUserId)
+	assert.True(db.IsUserSessionHost(webUserId))
+
+	db.RemoveWebUser(webUserToken)
+
+	{
+		hostUserId, isFound := db.GetSessionHost(sessionId)
+		assert.True(isFound)
+		assert.Equal(userId1, hostUserId)
+	}
+}
+
+func TestEndSession(t *testing.T) {
+	assert := require.New(t)
+	db := createDbAndConnect(t)
+	defer clearDb()
+	if db == nil {
+		t.Fail()
+		return
+	}
+	defer db.Disconnect()
+
+	userId1 := db.GetOrCreateTelegramUserId(123, "", "")
+	userId2 := db.GetOrCreateTelegramUserId(321, "", "")
+
+	sessionId, _, _, _ := db.CreateSession(userId1)
+	db.ConnectToSession(userId2, sessionId)
+	db.AddSessionSuggestedCommand(sessionId, "test")
+
+	webUserToken := int64(10)
+	db.AddWebUser(sessionId, webUserToken, "web", []string{"female"})
+	webUserId, _ := db.GetWebUserId(webUserToken)
+	db.AddWebMessage(webUserId, "message", 10)
+
+	// a player from another session should not be affected
+	userId3 := db.GetOrCreateTelegramUserId(456, "", "")
+	otherSessionId, _, _, _ := db.CreateSession(userId3)
+	otherWebUserToken := int64(20)
+	db.AddWebUser(otherSessionId, otherWebUserToken, "other web", []string{"female"})
+	otherWebUserId, _ := db.GetWebUserId(otherWebUserToken)
+	db.AddWebMessage(otherWebUserId, "message", 10)
+
+	db.EndSession(sessionId)
+
+	assert.False(db.DoesSessionExist(sessionId))
+	assert.Equal(int64(0), db.GetSessionSuggestedCommandCount(sessionId))
+	assert.Equal(int64(0), db.GetUsersCountInSession(sessionId, false))
+	assert.False(db.DoesWebUserExist(webUserToken))
+	{
+		_, isInSession1 := db.GetUserSession(userId1)
+		_, isInSession2 := db.GetUserSession(userId2)
+		assert.False(isInSession1)
+		assert.False(isInSession2)
+		commands, _ := db.GetNewRecentWebMessages(webUserId, -1)
+		assert.Equal(0, len(commands))
+	}
+
+	assert.True(db.DoesSessionExist(otherSessionId))
+	assert.True(db.DoesWebUserExist(otherWebUserToken))
+	{
+		commands, _ := db.GetNewRecentWebMessages(otherWebUserId, -1)
+		assert.Equal(1, len(commands))
+	}
+}
+
+func TestAbandonedSessions(t *testing.T) {
+	assert := require.New(t)
+	db := createDbAndConnect(t)
+	defer clearDb()
+	if db == nil {
+		t.Fail()
+		return
+	}
+	defer db.Disconnect()
+
+	userId := db.GetOrCreateTelegramUserId(123, "", "")
+	sessionId, _, _, _ := db.CreateSession(userId)
+
+	assert.Equal(0, len(db.GetSessionsWithoutTelegramUsers()))
+	assert.Equal(0, len(db.GetSessionsInactiveSince(time.Now().Add(-time.Hour).Unix())))
+	assert.Equal([]int64{sessionId}, db.GetSessionsInactiveSince(time.Now().Add(time.Hour).Unix()))
+
+	// simulate a session that lost its Telegram players without being cleaned up
+	db.db.Exec(fmt.Sprintf("UPDATE users SET current_session=NULL WHERE id=%d", userId))
+
+	assert.Equal([]int64{sessionId}, db.GetSessionsWithoutTelegramUsers())
+}
+
+func TestSessionPlaceholders(t *testing.T) {
+	assert := require.New(t)
+	db := createDbAndConnect(t)
+	defer clearDb()
+	if db == nil {
+		t.Fail()
+		return
+	}
+	defer db.Disconnect()
+
+	userId := db.GetOrCreateTelegramUserId(123, "", "")
+	sessionId, _, _, _ := db.CreateSession(userId)
+
+	assert.Equal("", db.GetSessionPlaceholders(sessionId))
+
+	db.SetSessionPlaceholders(sessionId, "female: 'f")
+	assert.Equal("female: 'f", db.GetSessionPlaceholders(sessionId))
+
+	db.SetSessionPlaceholders(sessionId, "")
+	assert.Equal("", db.GetSessionPlaceholders(sessionId))
+}
+
 func TestSessionMessageId(t *testing.T) {
 	assert := require.New(t)
 	db := createDbAndConnect(t)
@@ -349,7 +526,7 @@ func TestSuggestedCommands(t *testing.T) {
 	testCommand1 := "test'asd"
 	testCommand2 := "tefaasd'a"
 
-	sessionId, _, _ := db.CreateSession(userId1)
+	sessionId, _, _, _ := db.CreateSession(userId1)
 
 	assert.Equal(int64(0), db.GetSessionSuggestedCommandCount(sessionId))
 
@@ -370,8 +547,249 @@ func TestSuggestedCommands(t *testing.T) {
 		assert.Equal(int64(1), db.GetSessionSuggestedCommandCount(sessionId))
 	}
 
-	db.LeaveSession(userId1)
+	db.AddSessionSuggestedCommands(sessionId, []CategorizedCommand{{testCommand1, DareCategory}, {testCommand2, TruthCategory}})
+
+	{
+		commands := db.GetSessionSuggestedCommands(sessionId)
+		assert.Equal(3, len(commands))
+		assert.Equal(CategorizedCommand{testCommand1, DareCategory}, commands[1])
+		assert.Equal(CategorizedCommand{testCommand2, TruthCategory}, commands[2])
+		assert.Equal(int64(1), db.GetSessionSuggestedCommandCountInCategory(sessionId, TruthCategory))
+	}
+
+	{
+		_, isSucceeded := db.PopRandomMatchingSessionSuggestedCommand(sessionId, AnyCategory, func(command string) bool {
+			return false
+		})
+		assert.False(isSucceeded)
+		assert.Equal(int64(3), db.GetSessionSuggestedCommandCount(sessionId))
+	}
+
+	{
+		command, isSucceeded := db.PopRandomMatchingSessionSuggestedCommand(sessionId, AnyCategory, func(command string) bool {
+			return command == testCommand2
+		})
+		assert.True(isSucceeded)
+		assert.Equal(testCommand2, command)
+		assert.Equal(int64(2), db.GetSessionSuggestedCommandCount(sessionId))
+	}
+
+	db.EndSession(sessionId)
+	assert.Equal(int64(0), db.GetSessionSuggestedCommandCount(sessionId))
+}
+
+func TestTruthOrDare(t *testing.T) {
+	assert := require.New(t)
+	db := createDbAndConnect(t)
+	defer clearDb()
+	if db == nil {
+		t.Fail()
+		return
+	}
+	defer db.Disconnect()
+
+	userId := db.GetOrCreateTelegramUserId(123, "", "")
+	sessionId, _, _, _ := db.CreateSession(userId)
+
+	assert.False(db.IsSessionInTruthOrDareMode(sessionId))
+	db.SetSessionTruthOrDareMode(sessionId, true)
+	assert.True(db.IsSessionInTruthOrDareMode(sessionId))
+
+	db.AddSessionSuggestedCommandInCategory(sessionId, "truth", TruthCategory)
+	db.AddSessionSuggestedCommand(sessionId, "dare1")
+	db.AddSessionSuggestedCommandInCategory(sessionId, "dare2", DareCategory)
+
+	assert.Equal(int64(3), db.GetSessionSuggestedCommandCount(sessionId))
+	assert.Equal(int64(1), db.GetSessionSuggestedCommandCountInCategory(sessionId, TruthCategory))
+	assert.Equal(int64(2), db.GetSessionSuggestedCommandCountInCategory(sessionId, DareCategory))
+
+	{
+		command, isSucceeded := db.PopRandomMatchingSessionSuggestedCommand(sessionId, TruthCategory, func(string) bool { return true })
+		assert.True(isSucceeded)
+		assert.Equal("truth", command)
+	}
+
+	{
+		_, isSucceeded := db.PopRandomMatchingSessionSuggestedCommand(sessionId, TruthCategory, func(string) bool { return true })
+		assert.False(isSucceeded)
+	}
+
+	{
+		command, isSucceeded := db.PopRandomMatchingSessionSuggestedCommand(sessionId, DareCategory, func(string) bool { return true })
+		assert.True(isSucceeded)
+		assert.True(command == "dare1" || command == "dare2")
+	}
+
+	db.SetSessionTruthOrDareMode(sessionId, false)
+	assert.False(db.IsSessionInTruthOrDareMode(sessionId))
+}
+
+func TestSessionKing(t *testing.T) {
+	assert := require.New(t)
+	db := createDbAndConnect(t)
+	defer clearDb()
+	if db == nil {
+		t.Fail()
+		return
+	}
+	defer db.Disconnect()
+
+	userId1 := db.GetOrCreateTelegramUserId(123, "", "")
+	userId2 := db.GetOrCreateTelegramUserId(321, "", "")
+	sessionId, _, _, _ := db.CreateSession(userId1)
+	db.ConnectToSession(userId2, sessionId)
+
+	assert.Equal(KingModeOff, db.GetSessionKingMode(sessionId))
+	db.SetSessionKingMode(sessionId, KingModeRandom)
+	assert.Equal(KingModeRandom, db.GetSessionKingMode(sessionId))
+
+	{
+		_, isInSession := db.GetSessionKing(sessionId)
+		assert.False(isInSession)
+	}
+
+	db.SetSessionKing(sessionId, userId2)
+	{
+		kingUserId, isInSession := db.GetSessionKing(sessionId)
+		assert.True(isInSession)
+		assert.Equal(userId2, kingUserId)
+	}
+
+	db.LeaveSession(userId2)
+	{
+		kingUserId, isInSession := db.GetSessionKing(sessionId)
+		assert.False(isInSession)
+		assert.Equal(userId2, kingUserId)
+	}
+}
+
+func TestSessionSelectionPolicy(t *testing.T) {
+	assert := require.New(t)
+	db := createDbAndConnect(t)
+	defer clearDb()
+	if db == nil {
+		t.Fail()
+		return
+	}
+	defer db.Disconnect()
+
+	userId := db.GetOrCreateTelegramUserId(123, "", "")
+	sessionId, _, _, _ := db.CreateSession(userId)
+
+	assert.Equal(SelectionPolicyExponential, db.GetSessionSelectionPolicy(sessionId))
+	db.SetSessionSelectionPolicy(sessionId, SelectionPolicyRoundRobin)
+	assert.Equal(SelectionPolicyRoundRobin, db.GetSessionSelectionPolicy(sessionId))
+}
+
+func TestPenaltyCommands(t *testing.T) {
+	assert := require.New(t)
+	db := createDbAndConnect(t)
+	defer clearDb()
+	if db == nil {
+		t.Fail()
+		return
+	}
+	defer db.Disconnect()
+
+	userId := db.GetOrCreateTelegramUserId(123, "", "")
+	sessionId, _, _, _ := db.CreateSession(userId)
+
+	{
+		_, isFound := db.GetRandomMatchingSessionPenaltyCommand(sessionId, func(string) bool { return true })
+		assert.False(isFound)
+	}
+
+	db.AddSessionPenaltyCommand(sessionId, "drink")
+	db.AddSessionPenaltyCommand(sessionId, "sing")
+	assert.Equal(int64(2), db.GetSessionPenaltyCommandCount(sessionId))
 	assert.Equal(int64(0), db.GetSessionSuggestedCommandCount(sessionId))
+
+	{
+		command, isFound := db.GetRandomMatchingSessionPenaltyCommand(sessionId, func(command string) bool { return command == "sing" })
+		assert.True(isFound)
+		assert.Equal("sing", command)
+	}
+	{
+		_, isFound := db.GetRandomMatchingSessionPenaltyCommand(sessionId, func(command string) bool { return false })
+		assert.False(isFound)
+	}
+
+	// the drawn penalties stay in the pool
+	assert.Equal(int64(2), db.GetSessionPenaltyCommandCount(sessionId))
+
+	db.EndSession(sessionId)
+	assert.Equal(int64(0), db.GetSessionPenaltyCommandCount(sessionId))
+}
+
+func TestRevealedDares(t *testing.T) {
+	assert := require.New(t)
+	db := createDbAndConnect(t)
+	defer clearDb()
+	if db == nil {
+		t.Fail()
+		return
+	}
+	defer db.Disconnect()
+
+	userId1 := db.GetOrCreateTelegramUserId(123, "", "")
+	userId2 := db.GetOrCreateTelegramUserId(321, "", "")
+	db.SetUserName(userId1, "first")
+	db.SetUserName(userId2, "second")
+	sessionId, _, _, _ := db.CreateSession(userId1)
+	db.ConnectToSession(userId2, sessionId)
+
+	{
+		_, isFound := db.GetLastPendingRevealedDareId(sessionId)
+		assert.False(isFound)
+	}
+
+	dareId := db.AddRevealedDare(sessionId, "first kisses second", []int64{userId1, userId2})
+	{
+		dare, isFound := db.GetRevealedDare(dareId)
+		assert.True(isFound)
+		assert.Equal(sessionId, dare.SessionId)
+		assert.Equal("first kisses second", dare.Command)
+		assert.Equal(2, len(dare.Players))
+		assert.Equal(userId1, dare.Players[0].UserId)
+		assert.Equal("first", dare.Players[0].Name)
+		assert.Equal(DareStatePending, dare.Players[0].State)
+	}
+	{
+		lastDareId, isFound := db.GetLastPendingRevealedDareId(sessionId)
+		assert.True(isFound)
+		assert.Equal(dareId, lastDareId)
+	}
+
+	assert.True(db.SetRevealedDarePlayerState(dareId, userId1, DareStateDone))
+	assert.False(db.SetRevealedDarePlayerState(dareId, userId1, DareStateSkipped))
+
+	db.SetSessionSkipPenalty(sessionId, 2)
+	assert.Equal(2, db.GetSessionSkipPenalty(sessionId))
+	assert.True(db.SetRevealedDarePlayerState(dareId, userId2, DareStateSkipped))
+
+	{
+		_, isFound := db.GetLastPendingRevealedDareId(sessionId)
+		assert.False(isFound)
+	}
+
+	{
+		scores := db.GetSessionScores(sessionId)
+		assert.Equal(2, len(scores))
+		assert.Equal(userId1, scores[0].UserId)
+		assert.Equal(1, scores[0].DoneCount)
+		assert.Equal(0, scores[0].SkippedCount)
+		assert.Equal(0, scores[0].PenaltyPoints)
+		assert.Equal(userId2, scores[1].UserId)
+		assert.Equal(0, scores[1].DoneCount)
+		assert.Equal(1, scores[1].SkippedCount)
+		assert.Equal(2, scores[1].PenaltyPoints)
+	}
+
+	db.EndSession(sessionId)
+	{
+		_, isFound := db.GetRevealedDare(dareId)
+		assert.False(isFound)
+	}
 }
 
 func TestFTUE(t *testing.T) {
@@ -404,27 +822,175 @@ func TestIdleCount(t *testing.T) {
 	defer db.Disconnect()
 
 	userId1 := db.GetOrCreateTelegramUserId(123, "", "a")
-	db.SetUserGender(userId1, 1)
+	db.SetUserGroups(userId1, []string{"female"})
 	userId2 := db.GetOrCreateTelegramUserId(234, "", "b")
-	db.SetUserGender(userId2, 2)
+	db.SetUserGroups(userId2, []string{"male"})
 
-	sessionId, _, _ := db.CreateSession(userId1)
+	sessionId, _, _, _ := db.CreateSession(userId1)
 	db.ConnectToSession(userId2, sessionId)
 
-	assert.Equal([]SessionUserInfo{{userId1, 123, "a", 1, 0, false}, {userId2, 234, "b", 2, 0, false}}, db.GetUsersInSessionInfo(sessionId))
+	assert.Equal([]SessionUserInfo{{userId1, 123, "a", []string{"female"}, 0, false, false, nil, 0}, {userId2, 234, "b", []string{"male"}, 0, false, false, nil, 0}}, db.GetUsersInSessionInfo(sessionId))
 
 	db.UpdateUsersIdleCount([]int64{userId1, userId2}, 1, []int64{})
 
-	assert.Equal([]SessionUserInfo{{userId1, 123, "a", 1, 1, false}, {userId2, 234, "b", 2, 1, false}}, db.GetUsersInSessionInfo(sessionId))
+	assert.Equal([]SessionUserInfo{{userId1, 123, "a", []string{"female"}, 1, false, false, nil, 0}, {userId2, 234, "b", []string{"male"}, 1, false, false, nil, 0}}, db.GetUsersInSessionInfo(sessionId))
 
 	db.UpdateUsersIdleCount([]int64{userId1}, 2, []int64{userId2})
 
-	assert.Equal([]SessionUserInfo{{userId1, 123, "a", 1, 3, false}, {userId2, 234, "b", 2, 0, false}}, db.GetUsersInSessionInfo(sessionId))
+	assert.Equal([]SessionUserInfo{{userId1, 123, "a", []string{"female"}, 3, false, false, nil, 0}, {userId2, 234, "b", []string{"male"}, 0, false, false, nil, 0}}, db.GetUsersInSessionInfo(sessionId))
 
 	db.LeaveSession(userId1)
 	db.ConnectToSession(userId1, sessionId)
 
-	assert.Equal([]SessionUserInfo{{userId1, 123, "a", 1, 0, false}, {userId2, 234, "b", 2, 0, false}}, db.GetUsersInSessionInfo(sessionId))
+	assert.Equal([]SessionUserInfo{{userId1, 123, "a", []string{"female"}, 0, false, false, nil, 0}, {userId2, 234, "b", []string{"male"}, 0, false, false, nil, 0}}, db.GetUsersInSessionInfo(sessionId))
+}
+
+func TestPausedUsers(t *testing.T) {
+	assert := require.New(t)
+	db := createDbAndConnect(t)
+	defer clearDb()
+	if db == nil {
+		t.Fail()
+		return
+	}
+	defer db.Disconnect()
+
+	userId1 := db.GetOrCreateTelegramUserId(123, "", "a")
+	userId2 := db.GetOrCreateTelegramUserId(234, "", "b")
+	sessionId, _, _, _ := db.CreateSession(userId1)
+	db.ConnectToSession(userId2, sessionId)
+
+	assert.False(db.IsUserPaused(userId2))
+	db.SetUserPaused(userId2, true)
+	assert.True(db.IsUserPaused(userId2))
+
+	{
+		users := db.GetUsersInSessionInfo(sessionId)
+		assert.Equal(2, len(users))
+		assert.False(users[0].IsPaused)
+		assert.True(users[1].IsPaused)
+	}
+
+	// the players come back unpaused when they join a session again
+	db.LeaveSession(userId2)
+	assert.False(db.IsUserPaused(userId2))
+}
+
+func TestDeclinedTags(t *testing.T) {
+	assert := require.New(t)
+	db := createDbAndConnect(t)
+	defer clearDb()
+	if db == nil {
+		t.Fail()
+		return
+	}
+	defer db.Disconnect()
+
+	userId1 := db.GetOrCreateTelegramUserId(123, "", "a")
+	userId2 := db.GetOrCreateTelegramUserId(234, "", "b")
+	sessionId, _, _, _ := db.CreateSession(userId1)
+	db.ConnectToSession(userId2, sessionId)
+
+	assert.Empty(db.GetUserDeclinedTags(userId2))
+	db.SetUserDeclinedTags(userId2, []string{"kiss", "touch"})
+	assert.Equal([]string{"kiss", "touch"}, db.GetUserDeclinedTags(userId2))
+
+	{
+		users := db.GetUsersInSessionInfo(sessionId)
+		assert.Equal(2, len(users))
+		assert.Empty(users[0].DeclinedTags)
+		assert.Equal([]string{"kiss", "touch"}, users[1].DeclinedTags)
+	}
+
+	// the tags stay declined when the player leaves the session
+	db.LeaveSession(userId2)
+	assert.Equal([]string{"kiss", "touch"}, db.GetUserDeclinedTags(userId2))
+
+	db.SetUserDeclinedTags(userId2, nil)
+	assert.Empty(db.GetUserDeclinedTags(userId2))
+}
+
+func TestPairConstraints(t *testing.T) {
+	assert := require.New(t)
+	db := createDbAndConnect(t)
+	defer clearDb()
+	if db == nil {
+		t.Fail()
+		return
+	}
+	defer db.Disconnect()
+
+	userId1 := db.GetOrCreateTelegramUserId(123, "", "a")
+	userId2 := db.GetOrCreateTelegramUserId(234, "", "b")
+	userId3 := db.GetOrCreateTelegramUserId(345, "", "c")
+	sessionId, _, _, _ := db.CreateSession(userId1)
+	db.ConnectToSession(userId2, sessionId)
+	db.ConnectToSession(userId3, sessionId)
+
+	assert.Empty(db.GetSessionPairConstraints(sessionId))
+
+	db.SetPairConstraint(sessionId, userId1, userId2, PairConstraintNever)
+	db.SetPairConstraint(sessionId, userId2, userId1, PairConstraintNever)
+	db.SetPairConstraint(sessionId, userId3, userId1, PairConstraintOnly)
+	assert.Equal([]PairConstraintInfo{
+		{userId1, userId2, PairConstraintNever},
+		{userId2, userId1, PairConstraintNever},
+		{userId3, userId1, PairConstraintOnly},
+	}, db.GetSessionPairConstraints(sessionId))
+
+	// a player has one rule for each partner
+	db.SetPairConstraint(sessionId, userId1, userId2, PairConstraintOnly)
+	db.SetPairConstraint(sessionId, userId3, userId1, PairConstraintNone)
+	assert.Equal([]PairConstraintInfo{
+		{userId2, userId1, PairConstraintNever},
+		{userId1, userId2, PairConstraintOnly},
+	}, db.GetSessionPairConstraints(sessionId))
+
+	// the rules are forgotten when one of the players leaves
+	db.LeaveSession(userId2)
+	assert.Empty(db.GetSessionPairConstraints(sessionId))
+}
+
+func TestSessionTeams(t *testing.T) {
+	assert := require.New(t)
+	db := createDbAndConnect(t)
+	defer clearDb()
+	if db == nil {
+		t.Fail()
+		return
+	}
+	defer db.Disconnect()
+
+	userId1 := db.GetOrCreateTelegramUserId(123, "", "a")
+	userId2 := db.GetOrCreateTelegramUserId(234, "", "b")
+	userId3 := db.GetOrCreateTelegramUserId(345, "", "c")
+	sessionId, _, _, _ := db.CreateSession(userId1)
+	db.ConnectToSession(userId2, sessionId)
+	db.ConnectToSession(userId3, sessionId)
+
+	getTeams := func() map[int64]int {
+		teams := make(map[int64]int)
+		for _, user := range db.GetUsersInSessionInfo(sessionId) {
+			teams[user.UserId] = user.Team
+		}
+		return teams
+	}
+
+	assert.Equal(0, db.GetSessionTeamsCount(sessionId))
+	assert.Equal(map[int64]int{userId1: 0, userId2: 0, userId3: 0}, getTeams())
+
+	db.SetSessionTeams(sessionId, [][]int64{{userId1, userId3}, {userId2}})
+	assert.Equal(2, db.GetSessionTeamsCount(sessionId))
+	assert.Equal(map[int64]int{userId1: 1, userId2: 2, userId3: 1}, getTeams())
+
+	// the player who left and joined again is not in a team
+	db.LeaveSession(userId3)
+	db.ConnectToSession(userId3, sessionId)
+	assert.Equal(map[int64]int{userId1: 1, userId2: 2, userId3: 0}, getTeams())
+
+	db.SetSessionTeams(sessionId, nil)
+	assert.Equal(0, db.GetSessionTeamsCount(sessionId))
+	assert.Equal(map[int64]int{userId1: 0, userId2: 0, userId3: 0}, getTeams())
 }
 
 func TestAddWebUser(t *testing.T) {
@@ -441,16 +1007,16 @@ func TestAddWebUser(t *testing.T) {
 
 	// we can add web users only if we have a session
 	userId := db.GetOrCreateTelegramUserId(123, "", "test")
-	sessionId, _, _ := db.CreateSession(userId)
+	sessionId, _, _, _ := db.CreateSession(userId)
 
 	assert.False(db.DoesWebUserExist(webUserToken))
 
-	wasAdded := db.AddWebUser(sessionId, webUserToken, "test name", 2)
+	wasAdded := db.AddWebUser(sessionId, webUserToken, "test name", []string{"male"})
 	assert.True(wasAdded)
 
 	assert.True(db.DoesWebUserExist(webUserToken))
 
-	wasAdded = db.AddWebUser(sessionId, webUserToken, "test name 2", 1)
+	wasAdded = db.AddWebUser(sessionId, webUserToken, "test name 2", []string{"female"})
 	assert.False(wasAdded) // same token
 
 	assert.Equal(int64(1), db.GetUsersCountInSession(sessionId, true))
@@ -464,7 +1030,7 @@ func TestAddWebUser(t *testing.T) {
 			continue
 		}
 		assert.Equal("test name", db.GetUserName(user))
-		assert.Equal(2, db.GetUserGender(user))
+		assert.Equal([]string{"male"}, db.GetUserGroups(user))
 		userSessionId, isInSession := db.GetUserSession(user)
 		assert.True(isInSession)
 		assert.Equal(sessionId, userSessionId)
@@ -473,12 +1039,14 @@ func TestAddWebUser(t *testing.T) {
 	webUserId, isFound := db.GetWebUserId(webUserToken)
 	assert.True(isFound)
 
-	assert.Equal([]SessionUserInfo{{userId, 123, "test", 0, 0, false}, {webUserId, 10, "test name", 2, 0, true}}, db.GetUsersInSessionInfo(sessionId))
+	assert.Equal([]SessionUserInfo{{userId, 123, "test", nil, 0, false, false, nil, 0}, {webUserId, 10, "test name", []string{"male"}, 0, true, false, nil, 0}}, db.GetUsersInSessionInfo(sessionId))
 	sessionToken, _ := db.GetTokenFromSessionId(sessionId)
 
 	// web users are not counted for the session survival
-	db.LeaveSession(userId)
+	_, _, isSessionAbandoned := db.LeaveSession(userId)
+	assert.True(isSessionAbandoned)
 
+	db.EndSession(sessionId)
 	assert.False(db.DoesSessionExist(sessionId))
 	_, isSessionFound := db.GetSessionIdFromToken(sessionToken)
 	assert.False(isSessionFound)
@@ -500,19 +1068,72 @@ func TestRemoveWebUser(t *testing.T) {
 	webUserToken := int64(10)
 
 	userId := db.GetOrCreateTelegramUserId(123, "", "test")
-	sessionId, _, _ := db.CreateSession(userId)
+	sessionId, _, _, _ := db.CreateSession(userId)
 
-	db.AddWebUser(sessionId, webUserToken, "test name", 2)
+	db.AddWebUser(sessionId, webUserToken, "test name", []string{"male"})
 
 	assert.True(db.DoesWebUserExist(webUserToken))
 
-	db.RemoveWebUser(webUserToken)
+	assert.False(db.RemoveWebUser(webUserToken))
 
 	assert.False(db.DoesWebUserExist(webUserToken))
 
 	assert.Equal(int64(1), db.GetUsersCountInSession(sessionId, false))
 }
 
+func TestWebHostedSession(t *testing.T) {
+	assert := require.New(t)
+	db := createDbAndConnect(t)
+	defer clearDb()
+	if db == nil {
+		t.Fail()
+		return
+	}
+	defer db.Disconnect()
+
+	webUserToken := int64(10)
+	otherWebUserToken := int64(11)
+
+	sessionId, wasAdded := db.CreateWebSession(webUserToken, "host", []string{"female"})
+	assert.True(wasAdded)
+	assert.True(db.DoesSessionExist(sessionId))
+	assert.True(db.IsSessionWebHosted(sessionId))
+
+	webUserId, _ := db.GetWebUserId(webUserToken)
+	hostUserId, isFound := db.GetSessionHost(sessionId)
+	assert.True(isFound)
+	assert.Equal(webUserId, hostUserId)
+
+	_, wasAdded = db.CreateWebSession(webUserToken, "host 2", []string{"male"})
+	assert.False(wasAdded)
+
+	assert.Equal(0, len(db.GetSessionsWithoutTelegramUsers()))
+	assert.Equal(0, len(db.GetWebHostedSessionsInactiveSince(time.Now().Add(-time.Hour).Unix())))
+	assert.Equal([]int64{sessionId}, db.GetWebHostedSessionsInactiveSince(time.Now().Add(time.Hour).Unix()))
+
+	// a Telegram player leaving doesn't end the session while the web players are there
+	userId := db.GetOrCreateTelegramUserId(123, "", "")
+	db.ConnectToSession(userId, sessionId)
+	{
+		_, _, isSessionAbandoned := db.LeaveSession(userId)
+		assert.False(isSessionAbandoned)
+	}
+
+	db.AddWebUser(sessionId, otherWebUserToken, "other", []string{"male"})
+	assert.False(db.RemoveWebUser(webUserToken))
+
+	otherWebUserId, _ := db.GetWebUserId(otherWebUserToken)
+	hostUserId, _ = db.GetSessionHost(sessionId)
+	assert.Equal(otherWebUserId, hostUserId)
+
+	assert.True(db.RemoveWebUser(otherWebUserToken))
+	assert.True(db.DoesSessionExist(sessionId))
+
+	// sessions created from Telegram are not web hosted
+	telegramSessionId, _, _, _ := db.CreateSession(userId)
+	assert.False(db.IsSessionWebHosted(telegramSessionId))
+}
+
 func TestWebMessages(t *testing.T) {
 	assert := require.New(t)
 	db := createDbAndConnect(t)
@@ -524,10 +1145,10 @@ func TestWebMessages(t *testing.T) {
 	defer db.Disconnect()
 
 	userId := db.GetOrCreateTelegramUserId(123, "", "test")
-	sessionId, _, _ := db.CreateSession(userId)
+	sessionId, _, _, _ := db.CreateSession(userId)
 
 	webUserToken := int64(42)
-	db.AddWebUser(sessionId, webUserToken, "name", 1)
+	db.AddWebUser(sessionId, webUserToken, "name", []string{"female"})
 	webUserId, _ := db.GetWebUserId(webUserToken)
 
 	{
@@ -581,6 +1202,7 @@ func TestWebMessages(t *testing.T) {
 	}
 
 	db.LeaveSession(userId)
+	db.EndSession(sessionId)
 
 	{
 		commands, newLastIndex := db.GetNewRecentWebMessages(webUserId, 0)
@@ -603,10 +1225,10 @@ func TestWebMessagesClearing(t *testing.T) {
 	userId := db.GetOrCreateTelegramUserId(123, "", "test")
 
 	{
-		sessionId, _, _ := db.CreateSession(userId)
+		sessionId, _, _, _ := db.CreateSession(userId)
 
 		webUserToken := int64(42)
-		db.AddWebUser(sessionId, webUserToken, "name", 1)
+		db.AddWebUser(sessionId, webUserToken, "name", []string{"female"})
 		webUserId, _ := db.GetWebUserId(webUserToken)
 
 		db.AddWebMessage(webUserId, "command1", 10)
@@ -615,10 +1237,10 @@ func TestWebMessagesClearing(t *testing.T) {
 	}
 
 	{
-		sessionId, _, _ := db.CreateSession(userId)
+		sessionId, _, _, _ := db.CreateSession(userId)
 
 		webUserToken := int64(63)
-		db.AddWebUser(sessionId, webUserToken, "name", 1)
+		db.AddWebUser(sessionId, webUserToken, "name", []string{"female"})
 		webUserId, _ := db.GetWebUserId(webUserToken)
 
 		commands, newLastIndex := db.GetNewRecentWebMessages(webUserId, -1)
@@ -626,3 +1248,94 @@ func TestWebMessagesClearing(t *testing.T) {
 		assert.Equal(-1, newLastIndex)
 	}
 }
+
+func TestDarePacks(t *testing.T) {
+	assert := require.New(t)
+	db := createDbAndConnect(t)
+	defer clearDb()
+	if db == nil {
+		t.Fail()
+		return
+	}
+	defer db.Disconnect()
+
+	userId1 := db.GetOrCreateTelegramUserId(123, "", "")
+	userId2 := db.GetOrCreateTelegramUserId(321, "", "")
+
+	sessionId, _, _, _ := db.CreateSession(userId1)
+	db.AddSessionSuggestedCommand(sessionId, "test'1")
+	db.AddSessionSuggestedCommandInCategory(sessionId, "test2", TruthCategory)
+
+	assert.Equal(0, len(db.GetUserDarePacks(userId1)))
+
+	packId := db.SaveSessionCommandsAsPack(sessionId, userId1, "pack'1")
+
+	{
+		packs := db.GetUserDarePacks(userId1)
+		assert.Equal([]DarePackInfo{{packId, "pack'1", "", 2}}, packs)
+		assert.Equal(0, len(db.GetUserDarePacks(userId2)))
+	}
+
+	{
+		commands, isFound := db.GetDarePackCommands(packId, userId1)
+		assert.True(isFound)
+		assert.Equal([]CategorizedCommand{{"test'1", DareCategory}, {"test2", TruthCategory}}, commands)
+	}
+
+	// other users can't use packs that they don't own
+	{
+		_, isFound := db.GetDarePackCommands(packId, userId2)
+		assert.False(isFound)
+	}
+
+	// saving with the same name replaces the pack
+	db.PopRandomSessionSuggestedCommand(sessionId)
+	newPackId := db.SaveSessionCommandsAsPack(sessionId, userId1, "pack'1")
+
+	{
+		packs := db.GetUserDarePacks(userId1)
+		assert.Equal([]DarePackInfo{{newPackId, "pack'1", "", 1}}, packs)
+		commands, _ := db.GetDarePackCommands(newPackId, userId1)
+		assert.Equal(1, len(commands))
+	}
+
+	// packs survive the session
+	db.LeaveSession(userId1)
+	assert.Equal(1, len(db.GetUserDarePacks(userId1)))
+
+	db.DeleteDarePack(newPackId, userId2)
+	assert.Equal(1, len(db.GetUserDarePacks(userId1)))
+
+	db.DeleteDarePack(newPackId, userId1)
+	assert.Equal(0, len(db.GetUserDarePacks(userId1)))
+	{
+		_, isFound := db.GetDarePackCommands(newPackId, userId1)
+		assert.False(isFound)
+	}
+}
+
+func TestCreateDarePack(t *testing.T) {
+	assert := require.New(t)
+	db := createDbAndConnect(t)
+	defer clearDb()
+	if db == nil {
+		t.Fail()
+		return
+	}
+	defer db.Disconnect()
+
+	userId := db.GetOrCreateTelegramUserId(123, "", "")
+
+	packId := db.CreateDarePack(userId, "pack", "ru-ru", []CategorizedCommand{{"test'1", DareCategory}, {"test2", TruthCategory}})
+
+	assert.Equal([]DarePackInfo{{packId, "pack", "ru-ru", 2}}, db.GetUserDarePacks(userId))
+	{
+		commands, isFound := db.GetDarePackCommands(packId, userId)
+		assert.True(isFound)
+		assert.Equal([]CategorizedCommand{{"test'1", DareCategory}, {"test2", TruthCategory}}, commands)
+	}
+
+	newPackId := db.CreateDarePack(userId, "pack", "", []CategorizedCommand{{"test3", DareCategory}})
+
+	assert.Equal([]DarePackInfo{{newPackId, "pack", "", 1}}, db.GetUserDarePacks(userId))
+}
@@ -7,7 +7,7 @@ import (
 
 const (
 	minimalVersion = "0.1"
-	latestVersion  = "0.3"
+	latestVersion  = "0.18"
 )
 
 type dbUpdater struct {
@@ -57,6 +57,21 @@ func makeUpdaters(versionFrom string, versionTo string) (updaters []dbUpdater) {
 	return
 }
 
+func doesColumnExist(db *GameDb, table string, column string) bool {
+	rows, err := db.db.Query(fmt.Sprintf("SELECT 1 FROM pragma_table_info('%s') WHERE name='%s'", table, column))
+	if err != nil {
+		log.Fatalf("Error while reading table info: %s", err)
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Fatalf("Error while closing rows: %s", err)
+		}
+	}()
+
+	return rows.Next()
+}
+
 func makeAllUpdaters() []dbUpdater {
 	return []dbUpdater{
 		{
@@ -126,7 +141,107 @@ func makeAllUpdaters() []dbUpdater {
 		{
 			version: "0.4",
 			updateDb: func(db *GameDb) {
-				db.db.Exec("DROP TABLE recently_sent_commands")
+				db.db.Exec("DROP TABLE IF EXISTS recently_sent_commands")
+			},
+		},
+		{
+			version: "0.5",
+			updateDb: func(db *GameDb) {
+				db.db.Exec("ALTER TABLE sessions ADD COLUMN host_user_id INTEGER")
+				// the oldest Telegram user in each session becomes its host
+				db.db.Exec("UPDATE sessions SET host_user_id=(SELECT MIN(users.id) FROM users JOIN telegram_users ON users.id=telegram_users.user_id WHERE users.current_session=sessions.id)")
+			},
+		},
+		{
+			version: "0.6",
+			updateDb: func(db *GameDb) {
+				db.db.Exec("ALTER TABLE sessions ADD COLUMN last_activity_time INTEGER")
+				db.db.Exec("UPDATE sessions SET last_activity_time=strftime('%s', 'now')")
+			},
+		},
+		{
+			version: "0.7",
+			updateDb: func(db *GameDb) {
+				// only the databases that already ran the first dare packs schema (at version 0.6) have the table without this column,
+				// in the older ones the table was just created by ConnectDb with the column in it
+				if !doesColumnExist(db, "dare_packs", "language") {
+					db.db.Exec("ALTER TABLE dare_packs ADD COLUMN language TEXT NOT NULL DEFAULT ''")
+				}
+			},
+		},
+		{
+			version: "0.8",
+			updateDb: func(db *GameDb) {
+				db.db.Exec("ALTER TABLE sessions ADD COLUMN placeholders TEXT")
+			},
+		},
+		{
+			version: "0.9",
+			updateDb: func(db *GameDb) {
+				db.db.Exec("ALTER TABLE sessions ADD COLUMN truth_or_dare_mode INTEGER NOT NULL DEFAULT 0")
+				db.db.Exec("ALTER TABLE session_commands ADD COLUMN category INTEGER NOT NULL DEFAULT 0")
+			},
+		},
+		{
+			version: "0.10",
+			updateDb: func(db *GameDb) {
+				db.db.Exec("ALTER TABLE sessions ADD COLUMN king_mode INTEGER NOT NULL DEFAULT 0")
+				db.db.Exec("ALTER TABLE sessions ADD COLUMN king_user_id INTEGER")
+			},
+		},
+		{
+			version: "0.11",
+			updateDb: func(db *GameDb) {
+				db.db.Exec("ALTER TABLE sessions ADD COLUMN skip_penalty INTEGER NOT NULL DEFAULT 0")
+			},
+		},
+		{
+			version: "0.12",
+			updateDb: func(db *GameDb) {
+				db.db.Exec("ALTER TABLE sessions ADD COLUMN selection_policy INTEGER NOT NULL DEFAULT 0")
+			},
+		},
+		{
+			version: "0.13",
+			updateDb: func(db *GameDb) {
+				db.db.Exec("ALTER TABLE users ADD COLUMN is_paused INTEGER NOT NULL DEFAULT 0")
+			},
+		},
+		{
+			version: "0.14",
+			updateDb: func(db *GameDb) {
+				db.db.Exec("ALTER TABLE users ADD COLUMN declined_tags TEXT NOT NULL DEFAULT ''")
+			},
+		},
+		{
+			version: "0.15",
+			updateDb: func(db *GameDb) {
+				db.db.Exec("ALTER TABLE users ADD COLUMN group_keys TEXT NOT NULL DEFAULT ''")
+				// the old gender values were bit flags: 1 for female and 2 for male,
+				// the keys are the default ones from data/groups.json, if a config renames them
+				// the users need to choose their groups again
+				db.db.Exec("UPDATE users SET group_keys=CASE gender WHEN 1 THEN 'female' WHEN 2 THEN 'male' WHEN 3 THEN 'female,male' ELSE '' END")
+				db.db.Exec("ALTER TABLE users DROP COLUMN gender")
+			},
+		},
+		{
+			version: "0.16",
+			updateDb: func(db *GameDb) {
+				db.db.Exec("ALTER TABLE sessions ADD COLUMN teams_count INTEGER NOT NULL DEFAULT 0")
+				db.db.Exec("ALTER TABLE users ADD COLUMN current_session_team INTEGER NOT NULL DEFAULT 0")
+			},
+		},
+		{
+			version: "0.17",
+			updateDb: func(db *GameDb) {
+				db.db.Exec("ALTER TABLE sessions ADD COLUMN is_web_hosted INTEGER NOT NULL DEFAULT 0")
+			},
+		},
+		{
+			version: "0.18",
+			updateDb: func(db *GameDb) {
+				// the categories of the already saved commands weren't stored, so they stay dares
+				db.db.Exec("ALTER TABLE dare_pack_commands ADD COLUMN category INTEGER NOT NULL DEFAULT 0")
 			},
 		},
 	}
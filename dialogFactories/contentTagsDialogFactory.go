@@ -0,0 +1,65 @@
+package dialogFactories
+
+import (
+	"github.com/gameraccoon/telegram-bot-skeleton/dialog"
+	"github.com/gameraccoon/telegram-bot-skeleton/dialogFactory"
+	"github.com/gameraccoon/telegram-bot-skeleton/processing"
+	"github.com/gameraccoon/telegram-the-king-says-bot/staticFunctions"
+	"github.com/nicksnyder/go-i18n/i18n"
+)
+
+// the buttons are created from the configured tags, two in a row
+const contentTagsInRow = 2
+
+type contentTagsDialogFactory struct {
+}
+
+func MakeContentTagsDialogFactory() dialogFactory.DialogFactory {
+	return &(contentTagsDialogFactory{})
+}
+
+func (factory *contentTagsDialogFactory) createVariants(staticData *processing.StaticProccessStructs, userId int64) (variants []dialog.Variant) {
+	variants = make([]dialog.Variant, 0)
+
+	for i, tag := range staticFunctions.GetContentTagNames(staticData) {
+		variant := dialog.Variant{
+			RowId:        i/contentTagsInRow + 1,
+			AdditionalId: tag,
+		}
+
+		if staticFunctions.IsContentTagDeclined(staticData, userId, tag) {
+			variant.Id = "acc"
+			variant.Text = "🚫 #" + tag
+		} else {
+			variant.Id = "dec"
+			variant.Text = "✅ #" + tag
+		}
+
+		variants = append(variants, variant)
+	}
+	return
+}
+
+func (factory *contentTagsDialogFactory) MakeDialog(userId int64, trans i18n.TranslateFunc, staticData *processing.StaticProccessStructs, customData interface{}) *dialog.Dialog {
+	return &dialog.Dialog{
+		Text:     trans("select_content_tags"),
+		Variants: factory.createVariants(staticData, userId),
+	}
+}
+
+func (factory *contentTagsDialogFactory) ProcessVariant(variantId string, additionalId string, data *processing.ProcessData) bool {
+	var isDeclined bool
+	switch variantId {
+	case "dec":
+		isDeclined = true
+	case "acc":
+		isDeclined = false
+	default:
+		return false
+	}
+
+	if staticFunctions.SetContentTagDeclined(data.Static, data.UserId, additionalId, isDeclined) {
+		data.SubstituteDialog(data.Static.MakeDialogFn("ct", data.UserId, data.Trans, data.Static, nil))
+	}
+	return true
+}
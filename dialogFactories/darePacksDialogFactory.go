@@ -0,0 +1,120 @@
+package dialogFactories
+
+import (
+	"github.com/gameraccoon/telegram-bot-skeleton/dialog"
+	"github.com/gameraccoon/telegram-bot-skeleton/dialogFactory"
+	"github.com/gameraccoon/telegram-bot-skeleton/processing"
+	"github.com/gameraccoon/telegram-the-king-says-bot/staticFunctions"
+	"github.com/nicksnyder/go-i18n/i18n"
+	"strconv"
+)
+
+type darePacksDialogFactory struct {
+}
+
+func MakeDarePacksDialogFactory() dialogFactory.DialogFactory {
+	return &(darePacksDialogFactory{})
+}
+
+func (factory *darePacksDialogFactory) createVariants(userId int64, trans i18n.TranslateFunc, staticData *processing.StaticProccessStructs) (variants []dialog.Variant) {
+	variants = make([]dialog.Variant, 0)
+
+	packs := staticFunctions.GetDb(staticData).GetUserDarePacks(userId)
+	for i, pack := range packs {
+		packId := strconv.FormatInt(pack.Id, 10)
+		name := pack.Name
+		if pack.Language != "" {
+			name += " [" + pack.Language + "]"
+		}
+		variants = append(variants, dialog.Variant{
+			Id: "load",
+			Text: trans("dare_pack_item", map[string]interface{}{
+				"Name":  name,
+				"Count": pack.CommandsCount,
+			}),
+			RowId:        i + 1,
+			AdditionalId: packId,
+		})
+		variants = append(variants, dialog.Variant{
+			Id:           "exp",
+			Text:         trans("export_dare_pack"),
+			RowId:        i + 1,
+			AdditionalId: packId,
+		})
+		variants = append(variants, dialog.Variant{
+			Id:           "del",
+			Text:         trans("delete_dare_pack"),
+			RowId:        i + 1,
+			AdditionalId: packId,
+		})
+	}
+
+	variants = append(variants, dialog.Variant{
+		Id:    "imp",
+		Text:  trans("import_dare_pack"),
+		RowId: len(packs) + 1,
+	})
+	return
+}
+
+func (factory *darePacksDialogFactory) MakeDialog(userId int64, trans i18n.TranslateFunc, staticData *processing.StaticProccessStructs, customData interface{}) *dialog.Dialog {
+	textId := "dare_packs_title"
+	if len(staticFunctions.GetDb(staticData).GetUserDarePacks(userId)) == 0 {
+		textId = "no_dare_packs"
+	}
+
+	return &dialog.Dialog{
+		Text:     trans(textId),
+		Variants: factory.createVariants(userId, trans, staticData),
+	}
+}
+
+func loadDarePack(packId int64, data *processing.ProcessData) bool {
+	sessionId, isInSession := staticFunctions.GetDb(data.Static).GetUserSession(data.UserId)
+	if !isInSession {
+		data.SendMessage(data.Trans("no_session_error"), true)
+		return true
+	}
+
+	pack, isFound := staticFunctions.FindUserDarePack(data.Static, data.UserId, packId)
+	if !isFound {
+		data.SendMessage(data.Trans("dare_pack_not_found"), true)
+		return true
+	}
+
+	addedCount, _ := staticFunctions.LoadDarePackToSession(data.Static, data.UserId, sessionId, packId)
+	data.SendMessage(data.Trans("dare_pack_loaded", map[string]interface{}{
+		"Name":  pack.Name,
+		"Count": addedCount,
+	}), true)
+	return true
+}
+
+func deleteDarePack(packId int64, data *processing.ProcessData) bool {
+	staticFunctions.GetDb(data.Static).DeleteDarePack(packId, data.UserId)
+	data.SubstituteDialog(data.Static.MakeDialogFn("pk", data.UserId, data.Trans, data.Static, nil))
+	return true
+}
+
+func (factory *darePacksDialogFactory) ProcessVariant(variantId string, additionalId string, data *processing.ProcessData) bool {
+	if variantId == "imp" {
+		staticFunctions.StartDarePackImport(data)
+		return true
+	}
+
+	packId, err := strconv.ParseInt(additionalId, 10, 64)
+	if err != nil {
+		return false
+	}
+
+	switch variantId {
+	case "load":
+		return loadDarePack(packId, data)
+	case "exp":
+		staticFunctions.SendDarePackFile(data, packId)
+		return true
+	case "del":
+		return deleteDarePack(packId, data)
+	}
+	return false
+}
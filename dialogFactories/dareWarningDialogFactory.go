@@ -0,0 +1,102 @@
+package dialogFactories
+
+import (
+	"github.com/gameraccoon/telegram-bot-skeleton/dialog"
+	"github.com/gameraccoon/telegram-bot-skeleton/dialogFactory"
+	"github.com/gameraccoon/telegram-bot-skeleton/processing"
+	"github.com/gameraccoon/telegram-the-king-says-bot/staticFunctions"
+	"github.com/nicksnyder/go-i18n/i18n"
+	"strconv"
+)
+
+const pendingDareKey = "pendingDare"
+
+type dareWarningVariantPrototype struct {
+	id      string
+	textId  string
+	process func(int64, *processing.ProcessData) bool
+	rowId   int
+}
+
+type dareWarningDialogFactory struct {
+	variants []dareWarningVariantPrototype
+}
+
+func MakeDareWarningDialogFactory() dialogFactory.DialogFactory {
+	return &(dareWarningDialogFactory{
+		variants: []dareWarningVariantPrototype{
+			dareWarningVariantPrototype{
+				id:      "add",
+				textId:  "add_dare_anyway",
+				process: addPendingDare,
+				rowId:   1,
+			},
+			dareWarningVariantPrototype{
+				id:      "edit",
+				textId:  "rewrite_dare",
+				process: suggestAnother,
+				rowId:   2,
+			},
+		},
+	})
+}
+
+func addPendingDare(sessionId int64, data *processing.ProcessData) bool {
+	db := staticFunctions.GetDb(data.Static)
+	currentSessionId, isInSession := db.GetUserSession(data.UserId)
+
+	if !isInSession || sessionId != currentSessionId {
+		data.SendMessage(data.Trans("session_is_too_old"), true)
+		return true
+	}
+
+	command, isFound := data.Static.GetUserStateValue(data.UserId, pendingDareKey).(string)
+	if !isFound {
+		data.SendMessage(data.Trans("session_is_too_old"), true)
+		return true
+	}
+	data.Static.SetUserStateValue(data.UserId, pendingDareKey, nil)
+
+	addSuggestedCommand(sessionId, command, data)
+	return true
+}
+
+func (factory *dareWarningDialogFactory) createVariants(trans i18n.TranslateFunc, sessionId int64) (variants []dialog.Variant) {
+	variants = make([]dialog.Variant, 0)
+
+	for _, variant := range factory.variants {
+		variants = append(variants, dialog.Variant{
+			Id:           variant.id,
+			Text:         trans(variant.textId),
+			RowId:        variant.rowId,
+			AdditionalId: strconv.FormatInt(sessionId, 10),
+		})
+	}
+	return
+}
+
+func (factory *dareWarningDialogFactory) MakeDialog(userId int64, trans i18n.TranslateFunc, staticData *processing.StaticProccessStructs, customData interface{}) *dialog.Dialog {
+	db := staticFunctions.GetDb(staticData)
+
+	sessionId, _ := db.GetUserSession(userId)
+
+	text := ""
+	if analysis, ok := customData.(*staticFunctions.DareAnalysis); ok {
+		text = analysis.GetWarning(staticData, trans)
+	}
+
+	return &dialog.Dialog{
+		Text:     text,
+		Variants: factory.createVariants(trans, sessionId),
+	}
+}
+
+func (factory *dareWarningDialogFactory) ProcessVariant(variantId string, additionalId string, data *processing.ProcessData) bool {
+	sessionId, _ := strconv.ParseInt(additionalId, 10, 64)
+	for _, variant := range factory.variants {
+		if variant.id == variantId {
+			return variant.process(sessionId, data)
+		}
+	}
+	return false
+}
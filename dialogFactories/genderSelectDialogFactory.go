@@ -6,107 +6,76 @@ import (
 	"github.com/gameraccoon/telegram-bot-skeleton/processing"
 	"github.com/gameraccoon/telegram-the-king-says-bot/staticFunctions"
 	"github.com/nicksnyder/go-i18n/i18n"
-	"strconv"
 )
 
-type genderSelectVariantPrototype struct {
-	id      string
-	textId  string
-	process func(int64, *processing.ProcessData) bool
-	rowId   int
-}
+// the buttons are created from the configured groups, two in a row
+const groupsInRow = 2
 
 type genderSelectDialogFactory struct {
-	variants []genderSelectVariantPrototype
 }
 
 func MakeGenderSelectDialogFactory() dialogFactory.DialogFactory {
-	return &(genderSelectDialogFactory{
-		variants: []genderSelectVariantPrototype{
-			genderSelectVariantPrototype{
-				id:      "fe",
-				textId:  "gender_female",
-				process: selectGenderFemale,
-				rowId:   1,
-			},
-			genderSelectVariantPrototype{
-				id:      "ma",
-				textId:  "gender_male",
-				process: selectGenderMale,
-				rowId:   1,
-			},
-			genderSelectVariantPrototype{
-				id:      "bo",
-				textId:  "gender_both",
-				process: selectGenderBoth,
-				rowId:   2,
-			},
-			genderSelectVariantPrototype{
-				id:      "no",
-				textId:  "gender_none",
-				process: selectGenderNone,
-				rowId:   2,
-			},
-		},
-	})
+	return &(genderSelectDialogFactory{})
 }
 
-func selectGenderFemale(sessionId int64, data *processing.ProcessData) bool {
-	db := staticFunctions.GetDb(data.Static)
-	db.SetUserGender(data.UserId, 1)
-	data.SubstituteMessage(data.Trans("gender_changed"))
-	return true
-}
+func (factory *genderSelectDialogFactory) createVariants(staticData *processing.StaticProccessStructs, userId int64, trans i18n.TranslateFunc) (variants []dialog.Variant) {
+	variants = make([]dialog.Variant, 0)
 
-func selectGenderMale(sessionId int64, data *processing.ProcessData) bool {
-	db := staticFunctions.GetDb(data.Static)
-	db.SetUserGender(data.UserId, 2)
-	data.SubstituteMessage(data.Trans("gender_changed"))
-	return true
-}
+	userGroups := staticFunctions.GetDb(staticData).GetUserGroups(userId)
+	groups := staticFunctions.GetGroups(staticData)
+	for i := range groups {
+		variant := dialog.Variant{
+			Text:         staticFunctions.GetGroupName(&groups[i], trans),
+			RowId:        i/groupsInRow + 1,
+			AdditionalId: groups[i].Key,
+		}
 
-func selectGenderBoth(sessionId int64, data *processing.ProcessData) bool {
-	db := staticFunctions.GetDb(data.Static)
-	db.SetUserGender(data.UserId, 3)
-	data.SubstituteMessage(data.Trans("gender_changed"))
-	return true
-}
+		if isGroupSelected(userGroups, groups[i].Key) {
+			variant.Id = "rem"
+			variant.Text = "✔️ " + variant.Text
+		} else {
+			variant.Id = "add"
+		}
 
-func selectGenderNone(sessionId int64, data *processing.ProcessData) bool {
-	db := staticFunctions.GetDb(data.Static)
-	db.SetUserGender(data.UserId, 0)
-	data.SubstituteMessage(data.Trans("gender_changed"))
-	return true
-}
+		variants = append(variants, variant)
+	}
 
-func (factory *genderSelectDialogFactory) createVariants(trans i18n.TranslateFunc) (variants []dialog.Variant) {
-	variants = make([]dialog.Variant, 0)
+	variants = append(variants, dialog.Variant{
+		Id:    "done",
+		Text:  trans("groups_done"),
+		RowId: (len(groups)+groupsInRow-1)/groupsInRow + 1,
+	})
+	return
+}
 
-	for _, variant := range factory.variants {
-		variants = append(variants, dialog.Variant{
-			Id:    variant.id,
-			Text:  trans(variant.textId),
-			RowId: variant.rowId,
-		})
+func isGroupSelected(userGroups []string, key string) bool {
+	for _, group := range userGroups {
+		if group == key {
+			return true
+		}
 	}
-	return
+	return false
 }
 
 func (factory *genderSelectDialogFactory) MakeDialog(userId int64, trans i18n.TranslateFunc, staticData *processing.StaticProccessStructs, customData interface{}) *dialog.Dialog {
 	return &dialog.Dialog{
 		Text:     trans("select_gender"),
-		Variants: factory.createVariants(trans),
+		Variants: factory.createVariants(staticData, userId, trans),
 	}
 }
 
+// the player can be in several groups or in none of them, so the choice is confirmed separately
 func (factory *genderSelectDialogFactory) ProcessVariant(variantId string, additionalId string, data *processing.ProcessData) bool {
-	sessionId, _ := strconv.ParseInt(additionalId, 10, 64)
-	for _, variant := range factory.variants {
-		if variant.id == variantId {
-			isSucceeded := variant.process(sessionId, data)
-			staticFunctions.FirstSetUpStep4(data)
-			return isSucceeded
+	switch variantId {
+	case "add", "rem":
+		if staticFunctions.SetUserGroupSelected(data.Static, data.UserId, additionalId, variantId == "add") {
+			data.SubstituteDialog(data.Static.MakeDialogFn("gc", data.UserId, data.Trans, data.Static, nil))
 		}
+		return true
+	case "done":
+		data.SubstituteMessage(data.Trans("gender_changed"))
+		staticFunctions.FirstSetUpStep4(data)
+		return true
 	}
 	return false
 }
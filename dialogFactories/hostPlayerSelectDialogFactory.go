@@ -0,0 +1,102 @@
+package dialogFactories
+
+import (
+	"github.com/gameraccoon/telegram-bot-skeleton/dialog"
+	"github.com/gameraccoon/telegram-bot-skeleton/dialogFactory"
+	"github.com/gameraccoon/telegram-bot-skeleton/processing"
+	"github.com/gameraccoon/telegram-the-king-says-bot/staticFunctions"
+	"github.com/nicksnyder/go-i18n/i18n"
+	"strconv"
+)
+
+const (
+	hostActionKick     = "kick"
+	hostActionTransfer = "host"
+)
+
+type hostPlayerSelectDialogFactory struct {
+}
+
+func MakeHostPlayerSelectDialogFactory() dialogFactory.DialogFactory {
+	return &(hostPlayerSelectDialogFactory{})
+}
+
+func (factory *hostPlayerSelectDialogFactory) createVariants(userId int64, staticData *processing.StaticProccessStructs, action string) (variants []dialog.Variant) {
+	variants = make([]dialog.Variant, 0)
+
+	db := staticFunctions.GetDb(staticData)
+	sessionId, _ := db.GetUserSession(userId)
+
+	rowId := 1
+	for _, user := range db.GetUsersInSessionInfo(sessionId) {
+		if user.UserId == userId {
+			continue
+		}
+
+		variants = append(variants, dialog.Variant{
+			Id:           action,
+			Text:         user.Name,
+			RowId:        rowId,
+			AdditionalId: strconv.FormatInt(user.UserId, 10),
+		})
+		rowId++
+	}
+	return
+}
+
+func (factory *hostPlayerSelectDialogFactory) MakeDialog(userId int64, trans i18n.TranslateFunc, staticData *processing.StaticProccessStructs, customData interface{}) *dialog.Dialog {
+	action, _ := customData.(string)
+
+	textId := "select_player_to_kick"
+	if action == hostActionTransfer {
+		textId = "select_new_host"
+	}
+
+	return &dialog.Dialog{
+		Text:     trans(textId),
+		Variants: factory.createVariants(userId, staticData, action),
+	}
+}
+
+func (factory *hostPlayerSelectDialogFactory) ProcessVariant(variantId string, additionalId string, data *processing.ProcessData) bool {
+	targetUserId, err := strconv.ParseInt(additionalId, 10, 64)
+	if err != nil {
+		return false
+	}
+
+	db := staticFunctions.GetDb(data.Static)
+	sessionId, isInSession := db.GetUserSession(data.UserId)
+	if !isInSession {
+		data.SendMessage(data.Trans("no_session_error"), true)
+		return true
+	}
+
+	if !db.IsUserSessionHost(data.UserId) {
+		data.SubstituteMessage(data.Trans("not_session_host"))
+		return true
+	}
+
+	targetName := db.GetUserName(targetUserId)
+
+	switch variantId {
+	case hostActionKick:
+		if staticFunctions.KickPlayer(data.Static, sessionId, targetUserId) {
+			data.SubstituteMessage(data.Trans("player_kicked", map[string]interface{}{
+				"Name": targetName,
+			}))
+		} else {
+			data.SubstituteMessage(data.Trans("player_not_in_session"))
+		}
+		return true
+	case hostActionTransfer:
+		if staticFunctions.TransferSessionHost(data.Static, sessionId, targetUserId) {
+			data.SubstituteMessage(data.Trans("host_transferred", map[string]interface{}{
+				"Name": targetName,
+			}))
+		} else {
+			data.SubstituteMessage(data.Trans("player_not_in_session"))
+		}
+		return true
+	}
+	return false
+}
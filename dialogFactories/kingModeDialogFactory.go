@@ -0,0 +1,109 @@
+package dialogFactories
+
+import (
+	"github.com/gameraccoon/telegram-bot-skeleton/dialog"
+	"github.com/gameraccoon/telegram-bot-skeleton/dialogFactory"
+	"github.com/gameraccoon/telegram-bot-skeleton/processing"
+	"github.com/gameraccoon/telegram-the-king-says-bot/database"
+	"github.com/gameraccoon/telegram-the-king-says-bot/staticFunctions"
+	"github.com/nicksnyder/go-i18n/i18n"
+	"strconv"
+)
+
+type kingModeVariantPrototype struct {
+	id       string
+	textId   string
+	kingMode int
+	rowId    int
+}
+
+type kingModeDialogFactory struct {
+	variants []kingModeVariantPrototype
+}
+
+func MakeKingModeDialogFactory() dialogFactory.DialogFactory {
+	return &(kingModeDialogFactory{
+		variants: []kingModeVariantPrototype{
+			kingModeVariantPrototype{
+				id:       "off",
+				textId:   "king_mode_off",
+				kingMode: database.KingModeOff,
+				rowId:    1,
+			},
+			kingModeVariantPrototype{
+				id:       "ord",
+				textId:   "king_mode_in_order",
+				kingMode: database.KingModeInOrder,
+				rowId:    2,
+			},
+			kingModeVariantPrototype{
+				id:       "rnd",
+				textId:   "king_mode_random",
+				kingMode: database.KingModeRandom,
+				rowId:    3,
+			},
+			kingModeVariantPrototype{
+				id:       "dare",
+				textId:   "king_mode_picked_by_dare",
+				kingMode: database.KingModePickedByDare,
+				rowId:    4,
+			},
+		},
+	})
+}
+
+func (factory *kingModeDialogFactory) createVariants(trans i18n.TranslateFunc, sessionId int64, currentKingMode int) (variants []dialog.Variant) {
+	variants = make([]dialog.Variant, 0)
+
+	for _, variant := range factory.variants {
+		text := trans(variant.textId)
+		if variant.kingMode == currentKingMode {
+			text = "✔️ " + text
+		}
+
+		variants = append(variants, dialog.Variant{
+			Id:           variant.id,
+			Text:         text,
+			RowId:        variant.rowId,
+			AdditionalId: strconv.FormatInt(sessionId, 10),
+		})
+	}
+	return
+}
+
+func (factory *kingModeDialogFactory) MakeDialog(userId int64, trans i18n.TranslateFunc, staticData *processing.StaticProccessStructs, customData interface{}) *dialog.Dialog {
+	db := staticFunctions.GetDb(staticData)
+
+	sessionId, _ := db.GetUserSession(userId)
+
+	return &dialog.Dialog{
+		Text:     trans("select_king_mode"),
+		Variants: factory.createVariants(trans, sessionId, db.GetSessionKingMode(sessionId)),
+	}
+}
+
+func (factory *kingModeDialogFactory) ProcessVariant(variantId string, additionalId string, data *processing.ProcessData) bool {
+	sessionId, _ := strconv.ParseInt(additionalId, 10, 64)
+
+	db := staticFunctions.GetDb(data.Static)
+	currentSessionId, isInSession := db.GetUserSession(data.UserId)
+
+	if !isInSession || sessionId != currentSessionId {
+		data.SendMessage(data.Trans("session_is_too_old"), true)
+		return true
+	}
+
+	if !db.IsUserSessionHost(data.UserId) {
+		data.SendMessage(data.Trans("not_session_host"), true)
+		return true
+	}
+
+	for _, variant := range factory.variants {
+		if variant.id == variantId {
+			staticFunctions.SetSessionKingMode(data.Static, sessionId, variant.kingMode, data.UserId)
+			data.SubstituteDialog(data.Static.MakeDialogFn("km", data.UserId, data.Trans, data.Static, nil))
+			return true
+		}
+	}
+	return false
+}
@@ -34,10 +34,10 @@ func MakeNoSessionDialogFactory() dialogFactory.DialogFactory {
 }
 
 func createNewSession(data *processing.ProcessData) bool {
-	_, previousSessionId, wasInSession := staticFunctions.GetDb(data.Static).CreateSession(data.UserId)
+	_, previousSessionId, wasInSession, isPreviousSessionAbandoned := staticFunctions.GetDb(data.Static).CreateSession(data.UserId)
 	staticFunctions.SendSessionDialog(data)
 	if wasInSession {
-		staticFunctions.UpdateSessionDialogs(previousSessionId, data.Static)
+		staticFunctions.UpdateLeftSession(data.Static, previousSessionId, isPreviousSessionAbandoned)
 	}
 	return true
 }
@@ -0,0 +1,115 @@
+package dialogFactories
+
+import (
+	"github.com/gameraccoon/telegram-bot-skeleton/dialog"
+	"github.com/gameraccoon/telegram-bot-skeleton/dialogFactory"
+	"github.com/gameraccoon/telegram-bot-skeleton/processing"
+	"github.com/gameraccoon/telegram-the-king-says-bot/database"
+	"github.com/gameraccoon/telegram-the-king-says-bot/staticFunctions"
+	"github.com/nicksnyder/go-i18n/i18n"
+	"strconv"
+)
+
+type pairConstraintVariantPrototype struct {
+	id     string
+	prefix string
+	kind   int
+}
+
+type pairConstraintsDialogFactory struct {
+	variants []pairConstraintVariantPrototype
+}
+
+func MakePairConstraintsDialogFactory() dialogFactory.DialogFactory {
+	return &(pairConstraintsDialogFactory{
+		variants: []pairConstraintVariantPrototype{
+			pairConstraintVariantPrototype{
+				id:     "nev",
+				prefix: "🚫 ",
+				kind:   database.PairConstraintNever,
+			},
+			pairConstraintVariantPrototype{
+				id:     "onl",
+				prefix: "💞 ",
+				kind:   database.PairConstraintOnly,
+			},
+		},
+	})
+}
+
+// one row for each other player in the session, pressing the chosen rule again removes it
+func (factory *pairConstraintsDialogFactory) createVariants(staticData *processing.StaticProccessStructs, sessionId int64, userId int64) (variants []dialog.Variant) {
+	variants = make([]dialog.Variant, 0)
+
+	db := staticFunctions.GetDb(staticData)
+	constraints := db.GetSessionPairConstraints(sessionId)
+
+	rowId := 1
+	for _, user := range db.GetUsersInSessionInfo(sessionId) {
+		if user.UserId == userId {
+			continue
+		}
+
+		for _, variant := range factory.variants {
+			id := variant.id
+			text := variant.prefix + user.Name
+			if staticFunctions.GetPairConstraint(constraints, userId, user.UserId) == variant.kind {
+				id = "clr"
+				text = "✔️ " + text
+			}
+
+			variants = append(variants, dialog.Variant{
+				Id:           id,
+				Text:         text,
+				RowId:        rowId,
+				AdditionalId: strconv.FormatInt(user.UserId, 10),
+			})
+		}
+		rowId++
+	}
+	return
+}
+
+func (factory *pairConstraintsDialogFactory) MakeDialog(userId int64, trans i18n.TranslateFunc, staticData *processing.StaticProccessStructs, customData interface{}) *dialog.Dialog {
+	db := staticFunctions.GetDb(staticData)
+
+	sessionId, isInSession := db.GetUserSession(userId)
+	if !isInSession {
+		return &dialog.Dialog{
+			Text: trans("session_is_too_old"),
+		}
+	}
+
+	return &dialog.Dialog{
+		Text:     staticFunctions.GetPairConstraintsMessage(staticData, sessionId, userId, trans),
+		Variants: factory.createVariants(staticData, sessionId, userId),
+	}
+}
+
+func (factory *pairConstraintsDialogFactory) ProcessVariant(variantId string, additionalId string, data *processing.ProcessData) bool {
+	partnerUserId, _ := strconv.ParseInt(additionalId, 10, 64)
+
+	kind := database.PairConstraintNone
+	if variantId != "clr" {
+		isFound := false
+		for _, variant := range factory.variants {
+			if variant.id == variantId {
+				kind = variant.kind
+				isFound = true
+				break
+			}
+		}
+		if !isFound {
+			return false
+		}
+	}
+
+	sessionId, isInSession := staticFunctions.GetDb(data.Static).GetUserSession(data.UserId)
+	if !isInSession || !staticFunctions.SetPairConstraint(data.Static, sessionId, data.UserId, partnerUserId, kind) {
+		data.SendMessage(data.Trans("session_is_too_old"), true)
+		return true
+	}
+
+	data.SubstituteDialog(data.Static.MakeDialogFn("pc", data.UserId, data.Trans, data.Static, nil))
+	return true
+}
@@ -0,0 +1,96 @@
+package dialogFactories
+
+import (
+	"github.com/gameraccoon/telegram-bot-skeleton/dialog"
+	"github.com/gameraccoon/telegram-bot-skeleton/dialogFactory"
+	"github.com/gameraccoon/telegram-bot-skeleton/processing"
+	"github.com/gameraccoon/telegram-the-king-says-bot/database"
+	"github.com/gameraccoon/telegram-the-king-says-bot/staticFunctions"
+	"github.com/nicksnyder/go-i18n/i18n"
+	"strconv"
+)
+
+type revealedDareVariantPrototype struct {
+	id     string
+	textId string
+	state  int
+	rowId  int
+}
+
+type revealedDareDialogFactory struct {
+	variants []revealedDareVariantPrototype
+}
+
+func MakeRevealedDareDialogFactory() dialogFactory.DialogFactory {
+	return &(revealedDareDialogFactory{
+		variants: []revealedDareVariantPrototype{
+			revealedDareVariantPrototype{
+				id:     "done",
+				textId: "dare_done",
+				state:  database.DareStateDone,
+				rowId:  1,
+			},
+			revealedDareVariantPrototype{
+				id:     "skip",
+				textId: "dare_skip",
+				state:  database.DareStateSkipped,
+				rowId:  1,
+			},
+		},
+	})
+}
+
+func (factory *revealedDareDialogFactory) createVariants(trans i18n.TranslateFunc, dareId int64) (variants []dialog.Variant) {
+	variants = make([]dialog.Variant, 0)
+
+	for _, variant := range factory.variants {
+		variants = append(variants, dialog.Variant{
+			Id:           variant.id,
+			Text:         trans(variant.textId),
+			RowId:        variant.rowId,
+			AdditionalId: strconv.FormatInt(dareId, 10),
+		})
+	}
+	return
+}
+
+// customData is the id of the revealed dare
+func (factory *revealedDareDialogFactory) MakeDialog(userId int64, trans i18n.TranslateFunc, staticData *processing.StaticProccessStructs, customData interface{}) *dialog.Dialog {
+	db := staticFunctions.GetDb(staticData)
+
+	dareId, _ := customData.(int64)
+	dare, isFound := db.GetRevealedDare(dareId)
+	if !isFound {
+		return &dialog.Dialog{
+			Text: trans("session_is_too_old"),
+		}
+	}
+
+	var variants []dialog.Variant
+	if staticFunctions.CanMarkRevealedDare(staticData, &dare, userId) {
+		variants = factory.createVariants(trans, dareId)
+	}
+
+	return &dialog.Dialog{
+		Text:     staticFunctions.FormatRevealedDare(&dare, trans),
+		Variants: variants,
+	}
+}
+
+func (factory *revealedDareDialogFactory) ProcessVariant(variantId string, additionalId string, data *processing.ProcessData) bool {
+	dareId, _ := strconv.ParseInt(additionalId, 10, 64)
+
+	for _, variant := range factory.variants {
+		if variant.id == variantId {
+			result, _ := staticFunctions.MarkRevealedDare(data.Static, dareId, data.UserId, variant.state)
+			if result != staticFunctions.DareMarked {
+				data.SendMessage(staticFunctions.GetMarkDareErrorMessage(result, data.Trans), true)
+			}
+			if result != staticFunctions.DareNotFound {
+				data.SubstituteDialog(data.Static.MakeDialogFn("rd", data.UserId, data.Trans, data.Static, dareId))
+			}
+			return true
+		}
+	}
+	return false
+}
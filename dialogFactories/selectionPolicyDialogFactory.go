@@ -0,0 +1,109 @@
+package dialogFactories
+
+import (
+	"github.com/gameraccoon/telegram-bot-skeleton/dialog"
+	"github.com/gameraccoon/telegram-bot-skeleton/dialogFactory"
+	"github.com/gameraccoon/telegram-bot-skeleton/processing"
+	"github.com/gameraccoon/telegram-the-king-says-bot/database"
+	"github.com/gameraccoon/telegram-the-king-says-bot/staticFunctions"
+	"github.com/nicksnyder/go-i18n/i18n"
+	"strconv"
+)
+
+type selectionPolicyVariantPrototype struct {
+	id     string
+	textId string
+	policy int
+	rowId  int
+}
+
+type selectionPolicyDialogFactory struct {
+	variants []selectionPolicyVariantPrototype
+}
+
+func MakeSelectionPolicyDialogFactory() dialogFactory.DialogFactory {
+	return &(selectionPolicyDialogFactory{
+		variants: []selectionPolicyVariantPrototype{
+			selectionPolicyVariantPrototype{
+				id:     "exp",
+				textId: "selection_policy_exponential",
+				policy: database.SelectionPolicyExponential,
+				rowId:  1,
+			},
+			selectionPolicyVariantPrototype{
+				id:     "lin",
+				textId: "selection_policy_linear",
+				policy: database.SelectionPolicyLinear,
+				rowId:  2,
+			},
+			selectionPolicyVariantPrototype{
+				id:     "uni",
+				textId: "selection_policy_uniform",
+				policy: database.SelectionPolicyUniform,
+				rowId:  3,
+			},
+			selectionPolicyVariantPrototype{
+				id:     "rr",
+				textId: "selection_policy_round_robin",
+				policy: database.SelectionPolicyRoundRobin,
+				rowId:  4,
+			},
+		},
+	})
+}
+
+func (factory *selectionPolicyDialogFactory) createVariants(trans i18n.TranslateFunc, sessionId int64, currentPolicy int) (variants []dialog.Variant) {
+	variants = make([]dialog.Variant, 0)
+
+	for _, variant := range factory.variants {
+		text := trans(variant.textId)
+		if variant.policy == currentPolicy {
+			text = "✔️ " + text
+		}
+
+		variants = append(variants, dialog.Variant{
+			Id:           variant.id,
+			Text:         text,
+			RowId:        variant.rowId,
+			AdditionalId: strconv.FormatInt(sessionId, 10),
+		})
+	}
+	return
+}
+
+func (factory *selectionPolicyDialogFactory) MakeDialog(userId int64, trans i18n.TranslateFunc, staticData *processing.StaticProccessStructs, customData interface{}) *dialog.Dialog {
+	db := staticFunctions.GetDb(staticData)
+
+	sessionId, _ := db.GetUserSession(userId)
+
+	return &dialog.Dialog{
+		Text:     trans("select_selection_policy"),
+		Variants: factory.createVariants(trans, sessionId, db.GetSessionSelectionPolicy(sessionId)),
+	}
+}
+
+func (factory *selectionPolicyDialogFactory) ProcessVariant(variantId string, additionalId string, data *processing.ProcessData) bool {
+	sessionId, _ := strconv.ParseInt(additionalId, 10, 64)
+
+	db := staticFunctions.GetDb(data.Static)
+	currentSessionId, isInSession := db.GetUserSession(data.UserId)
+
+	if !isInSession || sessionId != currentSessionId {
+		data.SendMessage(data.Trans("session_is_too_old"), true)
+		return true
+	}
+
+	if !db.IsUserSessionHost(data.UserId) {
+		data.SendMessage(data.Trans("not_session_host"), true)
+		return true
+	}
+
+	for _, variant := range factory.variants {
+		if variant.id == variantId {
+			db.SetSessionSelectionPolicy(sessionId, variant.policy)
+			data.SubstituteDialog(data.Static.MakeDialogFn("sp", data.UserId, data.Trans, data.Static, nil))
+			return true
+		}
+	}
+	return false
+}
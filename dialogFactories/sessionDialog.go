@@ -1,23 +1,32 @@
 package dialogFactories
 
 import (
-	"fmt"
 	"github.com/gameraccoon/telegram-bot-skeleton/dialog"
 	"github.com/gameraccoon/telegram-bot-skeleton/dialogFactory"
 	"github.com/gameraccoon/telegram-bot-skeleton/processing"
-	static "github.com/gameraccoon/telegram-the-king-says-bot/staticData"
+	"github.com/gameraccoon/telegram-the-king-says-bot/database"
 	"github.com/gameraccoon/telegram-the-king-says-bot/staticFunctions"
 	"github.com/nicksnyder/go-i18n/i18n"
 	"log"
 	"strconv"
 )
 
+type sessionData struct {
+	userId        int64
+	sessionId     int64
+	isHost        bool
+	isTruthOrDare bool
+	hasPenalty    bool
+	isPaused      bool
+	staticData    *processing.StaticProccessStructs
+}
+
 type sessionVariantPrototype struct {
 	id         string
 	textId     string
 	process    func(int64, *processing.ProcessData) bool
 	rowId      int
-	isActiveFn func() bool
+	isActiveFn func(*sessionData) bool
 }
 
 type sessionDialogFactory struct {
@@ -40,21 +49,215 @@ func MakeSessionDialogFactory() dialogFactory.DialogFactory {
 				rowId:   1,
 			},
 			sessionVariantPrototype{
-				id:      "sugg",
-				textId:  "suggest_command",
-				process: suggestCommand,
+				id:      "roster",
+				textId:  "roster",
+				process: showRoster,
 				rowId:   2,
 			},
 			sessionVariantPrototype{
-				id:      "reve",
-				textId:  "reveal_command",
-				process: revealCommand,
+				id:      "pairs",
+				textId:  "pair_constraints",
+				process: showPairConstraints,
 				rowId:   2,
 			},
+			sessionVariantPrototype{
+				id:         "pause",
+				textId:     "pause_player",
+				process:    pausePlayer,
+				rowId:      2,
+				isActiveFn: isNotPaused,
+			},
+			sessionVariantPrototype{
+				id:         "resume",
+				textId:     "resume_player",
+				process:    resumePlayer,
+				rowId:      2,
+				isActiveFn: isPaused,
+			},
+			sessionVariantPrototype{
+				id:         "sugg",
+				textId:     "suggest_command",
+				process:    suggestCommand,
+				rowId:      3,
+				isActiveFn: isNormalMode,
+			},
+			sessionVariantPrototype{
+				id:         "reve",
+				textId:     "reveal_command",
+				process:    revealCommand,
+				rowId:      3,
+				isActiveFn: isNormalMode,
+			},
+			sessionVariantPrototype{
+				id:         "suggt",
+				textId:     "suggest_truth",
+				process:    suggestTruth,
+				rowId:      3,
+				isActiveFn: isTruthOrDareMode,
+			},
+			sessionVariantPrototype{
+				id:         "suggd",
+				textId:     "suggest_dare",
+				process:    suggestCommand,
+				rowId:      3,
+				isActiveFn: isTruthOrDareMode,
+			},
+			sessionVariantPrototype{
+				id:         "revt",
+				textId:     "reveal_truth",
+				process:    revealTruth,
+				rowId:      4,
+				isActiveFn: isTruthOrDareMode,
+			},
+			sessionVariantPrototype{
+				id:         "revd",
+				textId:     "reveal_dare",
+				process:    revealDare,
+				rowId:      4,
+				isActiveFn: isTruthOrDareMode,
+			},
+			sessionVariantPrototype{
+				id:         "revr",
+				textId:     "reveal_random",
+				process:    revealCommand,
+				rowId:      4,
+				isActiveFn: isTruthOrDareMode,
+			},
+			sessionVariantPrototype{
+				id:      "loadpk",
+				textId:  "load_dare_pack",
+				process: loadDarePackFromSession,
+				rowId:   5,
+			},
+			sessionVariantPrototype{
+				id:      "savepk",
+				textId:  "save_dare_pack",
+				process: saveDarePackFromSession,
+				rowId:   5,
+			},
+			sessionVariantPrototype{
+				id:      "suggp",
+				textId:  "suggest_penalty",
+				process: suggestPenalty,
+				rowId:   5,
+			},
+			sessionVariantPrototype{
+				id:         "kick",
+				textId:     "kick_player",
+				process:    kickPlayer,
+				rowId:      6,
+				isActiveFn: isSessionHost,
+			},
+			sessionVariantPrototype{
+				id:         "thost",
+				textId:     "transfer_host",
+				process:    transferHost,
+				rowId:      6,
+				isActiveFn: isSessionHost,
+			},
+			sessionVariantPrototype{
+				id:         "teams",
+				textId:     "teams",
+				process:    selectTeams,
+				rowId:      6,
+				isActiveFn: isSessionHost,
+			},
+			sessionVariantPrototype{
+				id:         "plch",
+				textId:     "session_placeholders",
+				process:    setSessionPlaceholders,
+				rowId:      7,
+				isActiveFn: isSessionHost,
+			},
+			sessionVariantPrototype{
+				id:         "tdon",
+				textId:     "enable_truth_or_dare",
+				process:    enableTruthOrDare,
+				rowId:      7,
+				isActiveFn: isHostInNormalMode,
+			},
+			sessionVariantPrototype{
+				id:         "tdoff",
+				textId:     "disable_truth_or_dare",
+				process:    disableTruthOrDare,
+				rowId:      7,
+				isActiveFn: isHostInTruthOrDareMode,
+			},
+			sessionVariantPrototype{
+				id:         "king",
+				textId:     "king_mode",
+				process:    selectKingMode,
+				rowId:      8,
+				isActiveFn: isSessionHost,
+			},
+			sessionVariantPrototype{
+				id:         "spol",
+				textId:     "selection_policy",
+				process:    selectSelectionPolicy,
+				rowId:      8,
+				isActiveFn: isSessionHost,
+			},
+			sessionVariantPrototype{
+				id:         "penon",
+				textId:     "enable_skip_penalty",
+				process:    enableSkipPenalty,
+				rowId:      8,
+				isActiveFn: isHostWithoutPenalty,
+			},
+			sessionVariantPrototype{
+				id:         "penoff",
+				textId:     "disable_skip_penalty",
+				process:    disableSkipPenalty,
+				rowId:      8,
+				isActiveFn: isHostWithPenalty,
+			},
+			sessionVariantPrototype{
+				id:         "endsess",
+				textId:     "end_session",
+				process:    endSession,
+				rowId:      9,
+				isActiveFn: isSessionHost,
+			},
 		},
 	})
 }
 
+func isSessionHost(sessionData *sessionData) bool {
+	return sessionData.isHost
+}
+
+func isNormalMode(sessionData *sessionData) bool {
+	return !sessionData.isTruthOrDare
+}
+
+func isTruthOrDareMode(sessionData *sessionData) bool {
+	return sessionData.isTruthOrDare
+}
+
+func isHostInNormalMode(sessionData *sessionData) bool {
+	return sessionData.isHost && !sessionData.isTruthOrDare
+}
+
+func isHostInTruthOrDareMode(sessionData *sessionData) bool {
+	return sessionData.isHost && sessionData.isTruthOrDare
+}
+
+func isPaused(sessionData *sessionData) bool {
+	return sessionData.isPaused
+}
+
+func isNotPaused(sessionData *sessionData) bool {
+	return !sessionData.isPaused
+}
+
+func isHostWithoutPenalty(sessionData *sessionData) bool {
+	return sessionData.isHost && !sessionData.hasPenalty
+}
+
+func isHostWithPenalty(sessionData *sessionData) bool {
+	return sessionData.isHost && sessionData.hasPenalty
+}
+
 func shareLink(sessionId int64, data *processing.ProcessData) bool {
 	db := staticFunctions.GetDb(data.Static)
 	staticData := data.Static
@@ -72,27 +275,11 @@ func shareLink(sessionId int64, data *processing.ProcessData) bool {
 		log.Printf("Can't find session token for sessionId %d", sessionId)
 	}
 
-	config, configCastSuccess := staticData.Config.(static.StaticConfiguration)
-
-	if !configCastSuccess {
-		config = static.StaticConfiguration{}
-	}
-
 	data.SendMessage("Share this link with your friends to invite them to the game:", true)
 
-	data.SendMessage(fmt.Sprintf(
-		"Link to join the game:\n%s/invite/%s",
-		config.ShareWebAddress,
-		sessionToken,
-	),
-		true)
+	data.SendMessage("Link to join the game:\n"+staticFunctions.GetInviteLink(staticData, sessionToken), true)
 
-	data.SendMessage(fmt.Sprintf(
-		"Or show them this QR code:\nhttps://api.qrserver.com/v1/create-qr-code/?size=150x150&margin=10&data=%s/invite/%s",
-		config.ShareWebAddress,
-		sessionToken,
-	),
-		false)
+	staticFunctions.SendInviteQrCode(data, sessionToken, "Or show them this QR code")
 
 	return true
 }
@@ -106,14 +293,62 @@ func disconnectSession(sessionId int64, data *processing.ProcessData) bool {
 		return true
 	}
 
-	_, wasInSession := db.LeaveSession(data.UserId)
+	_, wasInSession, isSessionAbandoned := db.LeaveSession(data.UserId)
 	data.SubstituteDialog(data.Static.MakeDialogFn("ns", data.UserId, data.Trans, data.Static, nil))
 	if wasInSession {
-		staticFunctions.UpdateSessionDialogs(sessionId, data.Static)
+		staticFunctions.UpdateLeftSession(data.Static, sessionId, isSessionAbandoned)
 	}
 	return true
 }
 
+func pausePlayer(sessionId int64, data *processing.ProcessData) bool {
+	return setPlayerPaused(sessionId, true, data)
+}
+
+func resumePlayer(sessionId int64, data *processing.ProcessData) bool {
+	return setPlayerPaused(sessionId, false, data)
+}
+
+func setPlayerPaused(sessionId int64, isPaused bool, data *processing.ProcessData) bool {
+	db := staticFunctions.GetDb(data.Static)
+	currentSessionId, isInSession := db.GetUserSession(data.UserId)
+
+	if !isInSession || sessionId != currentSessionId {
+		data.SendMessage(data.Trans("session_is_too_old"), true)
+		return true
+	}
+
+	db.SetUserPaused(data.UserId, isPaused)
+	staticFunctions.UpdateSessionDialogs(sessionId, data.Static)
+	return true
+}
+
+func showRoster(sessionId int64, data *processing.ProcessData) bool {
+	db := staticFunctions.GetDb(data.Static)
+	currentSessionId, isInSession := db.GetUserSession(data.UserId)
+
+	if !isInSession || sessionId != currentSessionId {
+		data.SendMessage(data.Trans("session_is_too_old"), true)
+		return true
+	}
+
+	data.SendMessage(staticFunctions.GetRosterMessage(data.Static, sessionId, data.Trans), true)
+	return true
+}
+
+func showPairConstraints(sessionId int64, data *processing.ProcessData) bool {
+	db := staticFunctions.GetDb(data.Static)
+	currentSessionId, isInSession := db.GetUserSession(data.UserId)
+
+	if !isInSession || sessionId != currentSessionId {
+		data.SendMessage(data.Trans("session_is_too_old"), true)
+		return true
+	}
+
+	data.SendDialog(data.Static.MakeDialogFn("pc", data.UserId, data.Trans, data.Static, nil))
+	return true
+}
+
 func suggestCommand(sessionId int64, data *processing.ProcessData) bool {
 	db := staticFunctions.GetDb(data.Static)
 	currentSessionId, isInSession := db.GetUserSession(data.UserId)
@@ -123,15 +358,49 @@ func suggestCommand(sessionId int64, data *processing.ProcessData) bool {
 		return true
 	}
 
-	data.SendMessage(data.Trans("suggest_command_msg"), true)
-	data.Static.SetUserStateTextProcessor(data.UserId, &processing.AwaitingTextProcessorData{
-		ProcessorId:  "suggestCommand",
-		AdditionalId: sessionId,
-	})
+	askForSuggestion(sessionId, database.DareCategory, data)
+	return true
+}
+
+func suggestTruth(sessionId int64, data *processing.ProcessData) bool {
+	db := staticFunctions.GetDb(data.Static)
+	currentSessionId, isInSession := db.GetUserSession(data.UserId)
+
+	if !isInSession || sessionId != currentSessionId {
+		data.SendMessage(data.Trans("session_is_too_old"), true)
+		return true
+	}
+
+	askForSuggestion(sessionId, database.TruthCategory, data)
+	return true
+}
+
+func suggestPenalty(sessionId int64, data *processing.ProcessData) bool {
+	db := staticFunctions.GetDb(data.Static)
+	currentSessionId, isInSession := db.GetUserSession(data.UserId)
+
+	if !isInSession || sessionId != currentSessionId {
+		data.SendMessage(data.Trans("session_is_too_old"), true)
+		return true
+	}
+
+	askForPenalty(sessionId, data)
 	return true
 }
 
 func revealCommand(sessionId int64, data *processing.ProcessData) bool {
+	return revealCommandInCategory(sessionId, database.AnyCategory, data)
+}
+
+func revealTruth(sessionId int64, data *processing.ProcessData) bool {
+	return revealCommandInCategory(sessionId, database.TruthCategory, data)
+}
+
+func revealDare(sessionId int64, data *processing.ProcessData) bool {
+	return revealCommandInCategory(sessionId, database.DareCategory, data)
+}
+
+func revealCommandInCategory(sessionId int64, category int, data *processing.ProcessData) bool {
 	db := staticFunctions.GetDb(data.Static)
 	currentSessionId, isInSession := db.GetUserSession(data.UserId)
 
@@ -140,30 +409,243 @@ func revealCommand(sessionId int64, data *processing.ProcessData) bool {
 		return true
 	}
 
-	command, isSucceeded := db.PopRandomSessionSuggestedCommand(sessionId)
-	staticFunctions.UpdateSessionDialogs(sessionId, data.Static)
+	if !staticFunctions.IsUserTurnToReveal(data.Static, sessionId, data.UserId) {
+		data.SendMessage(staticFunctions.GetNotYourTurnMessage(data.Static, sessionId, data.Trans), true)
+		return true
+	}
+
+	command, isSucceeded := staticFunctions.PopPlayableSuggestedCommand(data.Static, sessionId, category)
 
 	if isSucceeded {
+		staticFunctions.UpdateSessionDialogs(sessionId, data.Static)
 		staticFunctions.SendAdvancedCommand(data.Static, sessionId, command)
+	} else if db.GetSessionSuggestedCommandCountInCategory(sessionId, category) > 0 {
+		data.SendMessage(staticFunctions.GetNoPlayableDaresMessage(data.Static, sessionId, category, data.Trans), true)
 	} else {
-		data.SendMessage(data.Trans("no_suggested_commands"), true)
+		staticFunctions.UpdateSessionDialogs(sessionId, data.Static)
+		if category == database.TruthCategory {
+			data.SendMessage(data.Trans("no_suggested_truths"), true)
+		} else {
+			data.SendMessage(data.Trans("no_suggested_commands"), true)
+		}
+	}
+
+	return true
+}
+
+func enableTruthOrDare(sessionId int64, data *processing.ProcessData) bool {
+	return setTruthOrDareMode(sessionId, true, data)
+}
+
+func disableTruthOrDare(sessionId int64, data *processing.ProcessData) bool {
+	return setTruthOrDareMode(sessionId, false, data)
+}
+
+func setTruthOrDareMode(sessionId int64, isTruthOrDare bool, data *processing.ProcessData) bool {
+	db := staticFunctions.GetDb(data.Static)
+	currentSessionId, isInSession := db.GetUserSession(data.UserId)
+
+	if !isInSession || sessionId != currentSessionId {
+		data.SendMessage(data.Trans("session_is_too_old"), true)
+		return true
 	}
 
+	if !db.IsUserSessionHost(data.UserId) {
+		data.SendMessage(data.Trans("not_session_host"), true)
+		return true
+	}
+
+	db.SetSessionTruthOrDareMode(sessionId, isTruthOrDare)
+	staticFunctions.UpdateSessionDialogs(sessionId, data.Static)
 	return true
 }
 
-func (factory *sessionDialogFactory) createVariants(trans i18n.TranslateFunc, sessionId int64) (variants []dialog.Variant) {
+func enableSkipPenalty(sessionId int64, data *processing.ProcessData) bool {
+	return setSkipPenalty(sessionId, staticFunctions.DefaultSkipPenalty, data)
+}
+
+func disableSkipPenalty(sessionId int64, data *processing.ProcessData) bool {
+	return setSkipPenalty(sessionId, 0, data)
+}
+
+func setSkipPenalty(sessionId int64, penaltyPoints int, data *processing.ProcessData) bool {
+	db := staticFunctions.GetDb(data.Static)
+	currentSessionId, isInSession := db.GetUserSession(data.UserId)
+
+	if !isInSession || sessionId != currentSessionId {
+		data.SendMessage(data.Trans("session_is_too_old"), true)
+		return true
+	}
+
+	if !db.IsUserSessionHost(data.UserId) {
+		data.SendMessage(data.Trans("not_session_host"), true)
+		return true
+	}
+
+	db.SetSessionSkipPenalty(sessionId, penaltyPoints)
+	staticFunctions.UpdateSessionDialogs(sessionId, data.Static)
+	return true
+}
+
+func selectKingMode(sessionId int64, data *processing.ProcessData) bool {
+	db := staticFunctions.GetDb(data.Static)
+	currentSessionId, isInSession := db.GetUserSession(data.UserId)
+
+	if !isInSession || sessionId != currentSessionId {
+		data.SendMessage(data.Trans("session_is_too_old"), true)
+		return true
+	}
+
+	if !db.IsUserSessionHost(data.UserId) {
+		data.SendMessage(data.Trans("not_session_host"), true)
+		return true
+	}
+
+	data.SendDialog(data.Static.MakeDialogFn("km", data.UserId, data.Trans, data.Static, nil))
+	return true
+}
+
+func selectSelectionPolicy(sessionId int64, data *processing.ProcessData) bool {
+	db := staticFunctions.GetDb(data.Static)
+	currentSessionId, isInSession := db.GetUserSession(data.UserId)
+
+	if !isInSession || sessionId != currentSessionId {
+		data.SendMessage(data.Trans("session_is_too_old"), true)
+		return true
+	}
+
+	if !db.IsUserSessionHost(data.UserId) {
+		data.SendMessage(data.Trans("not_session_host"), true)
+		return true
+	}
+
+	data.SendDialog(data.Static.MakeDialogFn("sp", data.UserId, data.Trans, data.Static, nil))
+	return true
+}
+
+func selectTeams(sessionId int64, data *processing.ProcessData) bool {
+	db := staticFunctions.GetDb(data.Static)
+	currentSessionId, isInSession := db.GetUserSession(data.UserId)
+
+	if !isInSession || sessionId != currentSessionId {
+		data.SendMessage(data.Trans("session_is_too_old"), true)
+		return true
+	}
+
+	if !db.IsUserSessionHost(data.UserId) {
+		data.SendMessage(data.Trans("not_session_host"), true)
+		return true
+	}
+
+	data.SendDialog(data.Static.MakeDialogFn("tm", data.UserId, data.Trans, data.Static, nil))
+	return true
+}
+
+func loadDarePackFromSession(sessionId int64, data *processing.ProcessData) bool {
+	db := staticFunctions.GetDb(data.Static)
+	currentSessionId, isInSession := db.GetUserSession(data.UserId)
+
+	if !isInSession || sessionId != currentSessionId {
+		data.SendMessage(data.Trans("session_is_too_old"), true)
+		return true
+	}
+
+	data.SendDialog(data.Static.MakeDialogFn("pk", data.UserId, data.Trans, data.Static, nil))
+	return true
+}
+
+func saveDarePackFromSession(sessionId int64, data *processing.ProcessData) bool {
+	db := staticFunctions.GetDb(data.Static)
+	currentSessionId, isInSession := db.GetUserSession(data.UserId)
+
+	if !isInSession || sessionId != currentSessionId {
+		data.SendMessage(data.Trans("session_is_too_old"), true)
+		return true
+	}
+
+	staticFunctions.AskForDarePackName(data, sessionId)
+	return true
+}
+
+func sendHostPlayerSelectDialog(sessionId int64, data *processing.ProcessData, action string) bool {
+	db := staticFunctions.GetDb(data.Static)
+	currentSessionId, isInSession := db.GetUserSession(data.UserId)
+
+	if !isInSession || sessionId != currentSessionId {
+		data.SendMessage(data.Trans("session_is_too_old"), true)
+		return true
+	}
+
+	if !db.IsUserSessionHost(data.UserId) {
+		data.SendMessage(data.Trans("not_session_host"), true)
+		return true
+	}
+
+	if db.GetUsersCountInSession(sessionId, false) <= 1 {
+		data.SendMessage(data.Trans("no_other_players"), true)
+		return true
+	}
+
+	data.SendDialog(data.Static.MakeDialogFn("hp", data.UserId, data.Trans, data.Static, action))
+	return true
+}
+
+func kickPlayer(sessionId int64, data *processing.ProcessData) bool {
+	return sendHostPlayerSelectDialog(sessionId, data, hostActionKick)
+}
+
+func transferHost(sessionId int64, data *processing.ProcessData) bool {
+	return sendHostPlayerSelectDialog(sessionId, data, hostActionTransfer)
+}
+
+func endSession(sessionId int64, data *processing.ProcessData) bool {
+	db := staticFunctions.GetDb(data.Static)
+	currentSessionId, isInSession := db.GetUserSession(data.UserId)
+
+	if !isInSession || sessionId != currentSessionId {
+		data.SendMessage(data.Trans("session_is_too_old"), true)
+		return true
+	}
+
+	if !db.IsUserSessionHost(data.UserId) {
+		data.SendMessage(data.Trans("not_session_host"), true)
+		return true
+	}
+
+	staticFunctions.EndSession(data.Static, sessionId)
+	return true
+}
+
+func setSessionPlaceholders(sessionId int64, data *processing.ProcessData) bool {
+	db := staticFunctions.GetDb(data.Static)
+	currentSessionId, isInSession := db.GetUserSession(data.UserId)
+
+	if !isInSession || sessionId != currentSessionId {
+		data.SendMessage(data.Trans("session_is_too_old"), true)
+		return true
+	}
+
+	if !db.IsUserSessionHost(data.UserId) {
+		data.SendMessage(data.Trans("not_session_host"), true)
+		return true
+	}
+
+	staticFunctions.AskForSessionPlaceholders(data, sessionId)
+	return true
+}
+
+func (factory *sessionDialogFactory) createVariants(sessionData *sessionData, trans i18n.TranslateFunc) (variants []dialog.Variant) {
 	variants = make([]dialog.Variant, 0)
 
 	for _, variant := range factory.variants {
-		if variant.isActiveFn == nil || variant.isActiveFn() {
+		if variant.isActiveFn == nil || variant.isActiveFn(sessionData) {
 
 			variants = append(variants, dialog.Variant{
 				Id:           variant.id,
 				Text:         trans(variant.textId),
 				Url:          "",
 				RowId:        variant.rowId,
-				AdditionalId: strconv.FormatInt(sessionId, 10),
+				AdditionalId: strconv.FormatInt(sessionData.sessionId, 10),
 			})
 		}
 	}
@@ -176,14 +658,60 @@ func (factory *sessionDialogFactory) MakeDialog(userId int64, trans i18n.Transla
 	sessionId, _ := db.GetUserSession(userId)
 	countInSession := db.GetUsersCountInSession(sessionId, false)
 
+	hostName := "-"
+	hostUserId, isHostFound := db.GetSessionHost(sessionId)
+	if isHostFound {
+		hostName = db.GetUserName(hostUserId)
+	}
+
+	sessionData := sessionData{
+		userId:        userId,
+		sessionId:     sessionId,
+		isHost:        isHostFound && hostUserId == userId,
+		isTruthOrDare: db.IsSessionInTruthOrDareMode(sessionId),
+		hasPenalty:    db.GetSessionSkipPenalty(sessionId) > 0,
+		isPaused:      db.IsUserPaused(userId),
+		staticData:    staticData,
+	}
+
 	translationMap := map[string]interface{}{
 		"Participants": countInSession,
 		"Commands":     db.GetSessionSuggestedCommandCount(sessionId),
+		"Truths":       db.GetSessionSuggestedCommandCountInCategory(sessionId, database.TruthCategory),
+		"Dares":        db.GetSessionSuggestedCommandCountInCategory(sessionId, database.DareCategory),
+		"Host":         hostName,
+	}
+
+	titleId := "session_title"
+	if sessionData.isTruthOrDare {
+		titleId = "session_title_truth_or_dare"
+	}
+
+	text := trans(titleId, translationMap)
+	if pausedNames := staticFunctions.GetPausedPlayerNames(staticData, sessionId); len(pausedNames) > 0 {
+		text += "\n" + trans("paused_players", map[string]interface{}{
+			"Names": pausedNames,
+		})
+	}
+	if penaltiesCount := db.GetSessionPenaltyCommandCount(sessionId); penaltiesCount > 0 {
+		text += "\n" + trans("penalties_count", map[string]interface{}{
+			"Count": penaltiesCount,
+		})
+	}
+	if teamName := staticFunctions.GetPlayerTeamName(staticData, sessionId, userId, trans); len(teamName) > 0 {
+		text += "\n" + trans("current_team", map[string]interface{}{
+			"Team": teamName,
+		})
+	}
+	if kingUserId, isFound := staticFunctions.GetSessionKing(staticData, sessionId); isFound {
+		text += "\n" + trans("current_king", map[string]interface{}{
+			"King": db.GetUserName(kingUserId),
+		})
 	}
 
 	return &dialog.Dialog{
-		Text:     trans("session_title", translationMap),
-		Variants: factory.createVariants(trans, sessionId),
+		Text:     text,
+		Variants: factory.createVariants(&sessionData, trans),
 	}
 }
 
@@ -4,6 +4,7 @@ import (
 	"github.com/gameraccoon/telegram-bot-skeleton/dialog"
 	"github.com/gameraccoon/telegram-bot-skeleton/dialogFactory"
 	"github.com/gameraccoon/telegram-bot-skeleton/processing"
+	"github.com/gameraccoon/telegram-the-king-says-bot/database"
 	"github.com/gameraccoon/telegram-the-king-says-bot/staticFunctions"
 	"github.com/nicksnyder/go-i18n/i18n"
 	"strconv"
@@ -43,12 +44,43 @@ func suggestAnother(sessionId int64, data *processing.ProcessData) bool {
 		return true
 	}
 
-	data.SendMessage(data.Trans("suggest_command_msg"), true)
+	askForSuggestion(sessionId, getSuggestCategory(data), data)
+	return true
+}
+
+const suggestCategoryKey = "suggestCategory"
+
+// the category of the suggestions the user is currently adding
+func getSuggestCategory(data *processing.ProcessData) int {
+	category, isFound := data.Static.GetUserStateValue(data.UserId, suggestCategoryKey).(int)
+	if !isFound {
+		return database.DareCategory
+	}
+	return category
+}
+
+func askForSuggestion(sessionId int64, category int, data *processing.ProcessData) {
+	data.Static.SetUserStateValue(data.UserId, suggestCategoryKey, category)
+
+	if category == database.TruthCategory {
+		data.SendMessage(data.Trans("suggest_truth_msg"), true)
+	} else {
+		data.SendMessage(data.Trans("suggest_command_msg"), true)
+	}
+
 	data.Static.SetUserStateTextProcessor(data.UserId, &processing.AwaitingTextProcessorData{
 		ProcessorId:  "suggestCommand",
 		AdditionalId: sessionId,
 	})
-	return true
+}
+
+func askForPenalty(sessionId int64, data *processing.ProcessData) {
+	data.SendMessage(data.Trans("suggest_penalty_msg"), true)
+
+	data.Static.SetUserStateTextProcessor(data.UserId, &processing.AwaitingTextProcessorData{
+		ProcessorId:  "suggestPenalty",
+		AdditionalId: sessionId,
+	})
 }
 
 func (factory *suggestedConfirmedDialogFactory) createVariants(trans i18n.TranslateFunc, sessionId int64) (variants []dialog.Variant) {
@@ -0,0 +1,105 @@
+package dialogFactories
+
+import (
+	"github.com/gameraccoon/telegram-bot-skeleton/dialog"
+	"github.com/gameraccoon/telegram-bot-skeleton/dialogFactory"
+	"github.com/gameraccoon/telegram-bot-skeleton/processing"
+	"github.com/gameraccoon/telegram-the-king-says-bot/staticFunctions"
+	"github.com/nicksnyder/go-i18n/i18n"
+	"strconv"
+	"strings"
+)
+
+type teamsDialogFactory struct {
+}
+
+func MakeTeamsDialogFactory() dialogFactory.DialogFactory {
+	return &(teamsDialogFactory{})
+}
+
+// the variant ids are "spl" and "bal" followed by the number of teams
+// "bal" balances the teams by the participation groups of the players
+func (factory *teamsDialogFactory) createVariants(staticData *processing.StaticProccessStructs, sessionId int64, trans i18n.TranslateFunc) (variants []dialog.Variant) {
+	variants = make([]dialog.Variant, 0)
+	additionalId := strconv.FormatInt(sessionId, 10)
+
+	maxTeamsCount := staticFunctions.GetMaxTeamsCount(staticData, sessionId)
+	for teamsCount := staticFunctions.MinTeamsCount; teamsCount <= maxTeamsCount; teamsCount++ {
+		templateData := map[string]interface{}{
+			"Count": teamsCount,
+		}
+
+		variants = append(variants, dialog.Variant{
+			Id:           "spl" + strconv.Itoa(teamsCount),
+			Text:         trans("split_teams", templateData),
+			RowId:        1,
+			AdditionalId: additionalId,
+		})
+		variants = append(variants, dialog.Variant{
+			Id:           "bal" + strconv.Itoa(teamsCount),
+			Text:         trans("split_teams_balanced", templateData),
+			RowId:        2,
+			AdditionalId: additionalId,
+		})
+	}
+
+	if staticFunctions.GetDb(staticData).GetSessionTeamsCount(sessionId) > 0 {
+		variants = append(variants, dialog.Variant{
+			Id:           "clr",
+			Text:         trans("remove_teams"),
+			RowId:        3,
+			AdditionalId: additionalId,
+		})
+	}
+	return
+}
+
+func (factory *teamsDialogFactory) MakeDialog(userId int64, trans i18n.TranslateFunc, staticData *processing.StaticProccessStructs, customData interface{}) *dialog.Dialog {
+	db := staticFunctions.GetDb(staticData)
+
+	sessionId, _ := db.GetUserSession(userId)
+
+	return &dialog.Dialog{
+		Text:     trans("select_teams"),
+		Variants: factory.createVariants(staticData, sessionId, trans),
+	}
+}
+
+func (factory *teamsDialogFactory) ProcessVariant(variantId string, additionalId string, data *processing.ProcessData) bool {
+	sessionId, _ := strconv.ParseInt(additionalId, 10, 64)
+
+	db := staticFunctions.GetDb(data.Static)
+	currentSessionId, isInSession := db.GetUserSession(data.UserId)
+
+	if !isInSession || sessionId != currentSessionId {
+		data.SendMessage(data.Trans("session_is_too_old"), true)
+		return true
+	}
+
+	if !db.IsUserSessionHost(data.UserId) {
+		data.SendMessage(data.Trans("not_session_host"), true)
+		return true
+	}
+
+	if variantId == "clr" {
+		staticFunctions.RemoveSessionTeams(data.Static, sessionId)
+		return true
+	}
+
+	isBalanced := strings.HasPrefix(variantId, "bal")
+	if !isBalanced && !strings.HasPrefix(variantId, "spl") {
+		return false
+	}
+
+	teamsCount, err := strconv.Atoi(variantId[len("spl"):])
+	if err != nil {
+		return false
+	}
+
+	if !staticFunctions.SplitSessionIntoTeams(data.Static, sessionId, teamsCount, isBalanced) {
+		data.SendMessage(data.Trans("not_enough_players_for_teams", map[string]interface{}{
+			"Count": teamsCount,
+		}), true)
+	}
+	return true
+}
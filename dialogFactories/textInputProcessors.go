@@ -9,8 +9,12 @@ import (
 func GetTextInputProcessorManager() dialogManager.TextInputProcessorManager {
 	return dialogManager.TextInputProcessorManager{
 		Processors: dialogManager.TextProcessorsMap{
-			"changeName":     processChangeName,
-			"suggestCommand": processSuggestCommand,
+			"changeName":          processChangeName,
+			"suggestCommand":      processSuggestCommand,
+			"suggestPenalty":      processSuggestPenalty,
+			"darePackName":        processDarePackName,
+			"importDarePack":      processImportDarePackText,
+			"sessionPlaceholders": processSessionPlaceholders,
 		},
 	}
 }
@@ -38,10 +42,97 @@ func processChangeName(additionalId int64, data *processing.ProcessData) bool {
 	return true
 }
 
-func processSuggestCommand(additionalId int64, data *processing.ProcessData) bool {
+func addSuggestedCommand(sessionId int64, command string, data *processing.ProcessData) {
 	db := staticFunctions.GetDb(data.Static)
-	db.AddSessionSuggestedCommand(additionalId, data.Message)
-	staticFunctions.UpdateSessionDialogs(additionalId, data.Static)
+	db.AddSessionSuggestedCommandInCategory(sessionId, command, getSuggestCategory(data))
+	staticFunctions.UpdateSessionDialogs(sessionId, data.Static)
 	data.SendDialog(data.Static.MakeDialogFn("sc", data.UserId, data.Trans, data.Static, nil))
+}
+
+func processSuggestCommand(additionalId int64, data *processing.ProcessData) bool {
+	if fragment, isFound := staticFunctions.FindInvalidRandomValue(data.Message); isFound {
+		data.SendMessage(staticFunctions.GetInvalidRandomValueMessage(fragment, data.Trans), true)
+		data.Static.SetUserStateTextProcessor(data.UserId, &processing.AwaitingTextProcessorData{
+			ProcessorId:  "suggestCommand",
+			AdditionalId: additionalId,
+		})
+		return true
+	}
+
+	analysis := staticFunctions.AnalyzeDare(data.Static, additionalId, data.Message)
+	if !analysis.IsPlayable() {
+		// let the author decide whether the dare should be queued as it is
+		data.Static.SetUserStateValue(data.UserId, pendingDareKey, data.Message)
+		data.SendDialog(data.Static.MakeDialogFn("dw", data.UserId, data.Trans, data.Static, &analysis))
+		return true
+	}
+
+	addSuggestedCommand(additionalId, data.Message, data)
+	return true
+}
+
+func processSuggestPenalty(additionalId int64, data *processing.ProcessData) bool {
+	db := staticFunctions.GetDb(data.Static)
+	sessionId, isInSession := db.GetUserSession(data.UserId)
+	if !isInSession || sessionId != additionalId {
+		data.SendMessage(data.Trans("session_is_too_old"), true)
+		return true
+	}
+
+	if fragment, isFound := staticFunctions.FindInvalidRandomValue(data.Message); isFound {
+		data.SendMessage(staticFunctions.GetInvalidRandomValueMessage(fragment, data.Trans), true)
+		askForPenalty(sessionId, data)
+		return true
+	}
+
+	// the penalty should name the player who skipped a dare
+	if !staticFunctions.IsPenaltyValid(staticFunctions.GetSessionPlaceholders(data.Static, sessionId), data.Message) {
+		data.SendMessage(data.Trans("invalid_penalty"), true)
+		askForPenalty(sessionId, data)
+		return true
+	}
+
+	db.AddSessionPenaltyCommand(sessionId, data.Message)
+	staticFunctions.UpdateSessionDialogs(sessionId, data.Static)
+	data.SendMessage(data.Trans("penalty_added"), true)
+	return true
+}
+
+func processDarePackName(additionalId int64, data *processing.ProcessData) bool {
+	sessionId, isInSession := staticFunctions.GetDb(data.Static).GetUserSession(data.UserId)
+	if !isInSession || sessionId != additionalId {
+		data.SendMessage(data.Trans("session_is_too_old"), true)
+		return true
+	}
+
+	if len(data.Message) == 0 {
+		staticFunctions.AskForDarePackName(data, sessionId)
+		return true
+	}
+
+	staticFunctions.SaveSessionDarePack(data, sessionId, data.Message)
+	return true
+}
+
+func processImportDarePackText(additionalId int64, data *processing.ProcessData) bool {
+	// we are waiting for a file, not for a text
+	staticFunctions.StartDarePackImport(data)
+	return true
+}
+
+func processSessionPlaceholders(additionalId int64, data *processing.ProcessData) bool {
+	db := staticFunctions.GetDb(data.Static)
+	sessionId, isInSession := db.GetUserSession(data.UserId)
+	if !isInSession || sessionId != additionalId {
+		data.SendMessage(data.Trans("session_is_too_old"), true)
+		return true
+	}
+
+	if !db.IsUserSessionHost(data.UserId) {
+		data.SendMessage(data.Trans("not_session_host"), true)
+		return true
+	}
+
+	staticFunctions.SetSessionPlaceholders(data, sessionId, data.Message)
 	return true
 }
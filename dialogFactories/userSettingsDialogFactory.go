@@ -7,6 +7,7 @@ import (
 	static "github.com/gameraccoon/telegram-the-king-says-bot/staticData"
 	"github.com/gameraccoon/telegram-the-king-says-bot/staticFunctions"
 	"github.com/nicksnyder/go-i18n/i18n"
+	"strings"
 )
 
 type userSettingsData struct {
@@ -47,6 +48,13 @@ func MakeUserSettingsDialogFactory() dialogFactory.DialogFactory {
 				process: changeGender,
 				rowId:   3,
 			},
+			userSettingsVariantPrototype{
+				id:         "tags",
+				textId:     "change_content_tags",
+				process:    changeContentTags,
+				rowId:      4,
+				isActiveFn: hasContentTags,
+			},
 		},
 	})
 }
@@ -70,6 +78,15 @@ func changeGender(userId int64, data *processing.ProcessData) bool {
 	return true
 }
 
+func changeContentTags(userId int64, data *processing.ProcessData) bool {
+	data.SubstituteDialog(data.Static.MakeDialogFn("ct", data.UserId, data.Trans, data.Static, nil))
+	return true
+}
+
+func hasContentTags(settingsData *userSettingsData) bool {
+	return len(staticFunctions.GetContentTagNames(settingsData.staticData)) > 0
+}
+
 func (factory *userSettingsDialogFactory) createVariants(settingsData *userSettingsData, trans i18n.TranslateFunc) (variants []dialog.Variant) {
 	variants = make([]dialog.Variant, 0)
 
@@ -110,10 +127,16 @@ func (factory *userSettingsDialogFactory) MakeDialog(userId int64, trans i18n.Tr
 		}
 	}
 
+	declinedTags := "-"
+	if tags := db.GetUserDeclinedTags(userId); len(tags) > 0 {
+		declinedTags = "#" + strings.Join(tags, ", #")
+	}
+
 	translationMap := map[string]interface{}{
-		"Name":   db.GetUserName(userId),
-		"Lang":   langName,
-		"Gender": staticFunctions.GetGenderNameFromId(db.GetUserGender(userId), trans),
+		"Name":         db.GetUserName(userId),
+		"Lang":         langName,
+		"Gender":       staticFunctions.GetGroupNames(staticData, db.GetUserGroups(userId), trans),
+		"DeclinedTags": declinedTags,
 	}
 
 	return &dialog.Dialog{
@@ -8,6 +8,7 @@ require (
 	github.com/iohub/ahocorasick v0.0.0-20240118134817-7d0e3abf6181
 	github.com/mattn/go-sqlite3 v1.14.22
 	github.com/nicksnyder/go-i18n v1.10.3
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
 	github.com/stretchr/testify v1.8.2
 )
 
@@ -0,0 +1,1055 @@
+package httpServer
+
+import (
+	"encoding/json"
+	"github.com/gameraccoon/telegram-bot-skeleton/processing"
+	"github.com/gameraccoon/telegram-the-king-says-bot/database"
+	"github.com/gameraccoon/telegram-the-king-says-bot/staticFunctions"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const apiV1Prefix = "/api/v1/"
+
+const streamKeepAliveInterval = 30 * time.Second
+
+const maxPlayerNameLength = 20
+
+// the codes let the web client react to the errors without parsing the messages
+const (
+	errorUnknownApiMethod     = "unknown_api_method"
+	errorInvalidMethod        = "invalid_method"
+	errorInternal             = "internal_error"
+	errorInvalidRequest       = "invalid_request"
+	errorGameNotFound         = "game_not_found"
+	errorPlayerNotFound       = "player_not_found"
+	errorTargetPlayerNotFound = "target_player_not_found"
+	errorNotHost              = "not_host"
+	errorNotYourTurn          = "not_your_turn"
+	errorDareNotPlayable      = "dare_not_playable"
+	errorNoDares              = "no_dares"
+	errorNoPlayableDares      = "no_playable_dares"
+	errorDareNotFound         = "dare_not_found"
+	errorDareMarkNotAllowed   = "dare_mark_not_allowed"
+	errorDareAlreadyMarked    = "dare_already_marked"
+	errorNotEnoughPlayers     = "not_enough_players"
+)
+
+type apiError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type errorResponse struct {
+	Error apiError `json:"error"`
+}
+
+type okResponse struct {
+	Ok bool `json:"ok"`
+}
+
+// the tokens don't fit into JavaScript numbers, so they are sent as strings
+type playerRequest struct {
+	PlayerToken int64 `json:"playerToken,string"`
+}
+
+func (request *playerRequest) getPlayerToken() int64 {
+	return request.PlayerToken
+}
+
+type playerTokenRequest interface {
+	getPlayerToken() int64
+}
+
+type targetPlayerRequest struct {
+	playerRequest
+	TargetUserId int64 `json:"targetUserId"`
+}
+
+type enabledRequest struct {
+	playerRequest
+	Enabled bool `json:"enabled"`
+}
+
+type joinRequest struct {
+	GameId string `json:"gameId"`
+	Name   string `json:"name"`
+	// the keys of the groups, empty if the player doesn't participate in any group
+	Groups []string `json:"groups"`
+}
+
+type joinResponse struct {
+	PlayerToken int64 `json:"playerToken,string"`
+}
+
+type createGameRequest struct {
+	Name string `json:"name"`
+	// the keys of the groups, empty if the player doesn't participate in any group
+	Groups []string `json:"groups"`
+}
+
+type createGameResponse struct {
+	PlayerToken int64 `json:"playerToken,string"`
+	// the id to invite other players with
+	GameId string `json:"gameId"`
+}
+
+type renameRequest struct {
+	playerRequest
+	Name string `json:"name"`
+}
+
+type changeGroupsRequest struct {
+	playerRequest
+	Groups []string `json:"groups"`
+}
+
+type groupResponse struct {
+	Key  string `json:"key"`
+	Name string `json:"name"`
+}
+
+type suggestRequest struct {
+	playerRequest
+	Command string `json:"command"`
+	// "truth", "dare", "penalty" or empty
+	Category string `json:"category"`
+	// adds the dare even if it doesn't fit the players in the game
+	Force bool `json:"force"`
+}
+
+type revealRequest struct {
+	playerRequest
+	// "truth", "dare" or empty for any category
+	Category string `json:"category"`
+}
+
+type kingModeRequest struct {
+	playerRequest
+	Mode int `json:"mode"`
+}
+
+type selectionPolicyRequest struct {
+	playerRequest
+	Policy int `json:"policy"`
+}
+
+// zero teams removes the teams of the session
+type teamsRequest struct {
+	playerRequest
+	Count    int  `json:"count"`
+	Balanced bool `json:"balanced"`
+}
+
+type pauseRequest struct {
+	playerRequest
+	Paused bool `json:"paused"`
+}
+
+type contentTagRequest struct {
+	playerRequest
+	Tag      string `json:"tag"`
+	Declined bool   `json:"declined"`
+}
+
+type pairConstraintRequest struct {
+	targetPlayerRequest
+	Kind int `json:"kind"`
+}
+
+type markDareRequest struct {
+	playerRequest
+	DareId int64 `json:"dareId"`
+	// "done" or "skip"
+	Result string `json:"result"`
+}
+
+type scoreResponse struct {
+	Score string `json:"score"`
+}
+
+type contentTagState struct {
+	Name     string `json:"name"`
+	Declined bool   `json:"declined"`
+}
+
+// the pairing rules between the player and another player of the session
+type pairState struct {
+	UserId int64  `json:"userId"`
+	Name   string `json:"name"`
+	Mine   int    `json:"mine"`
+	Theirs int    `json:"theirs"`
+}
+
+type rosterPlayerState struct {
+	UserId int64  `json:"userId"`
+	Name   string `json:"name"`
+	// the names of the groups of the player
+	Groups   string `json:"groups"`
+	IsHost   bool   `json:"isHost"`
+	IsPaused bool   `json:"isPaused"`
+	Team     string `json:"team"`
+}
+
+// the state of the session as the player sees it
+type sessionStateResponse struct {
+	LastMessageIdx int   `json:"lastMessageIdx"`
+	Players        int64 `json:"players"`
+	Suggestions    int64 `json:"suggestions"`
+	Truths         int64 `json:"truths"`
+	Dares          int64 `json:"dares"`
+	Penalties      int64 `json:"penalties"`
+	TruthOrDare    bool  `json:"truthOrDare"`
+	IsHost         bool  `json:"isHost"`
+	// the name of the player whose turn it is, empty if the session isn't played in turns
+	King            string `json:"king"`
+	IsKing          bool   `json:"isKing"`
+	KingMode        int    `json:"kingMode"`
+	SelectionPolicy int    `json:"selectionPolicy"`
+	// the last revealed dare that the player can mark as done or skipped, zero if there is none
+	MarkableDareId int64             `json:"markableDareId"`
+	Penalty        bool              `json:"penalty"`
+	IsPaused       bool              `json:"isPaused"`
+	Paused         string            `json:"paused"`
+	ContentTags    []contentTagState `json:"contentTags"`
+	Pairs          []pairState       `json:"pairs"`
+	// the team of the player with its placeholder, empty if the player is not in a team
+	Team          string   `json:"team"`
+	TeamsCount    int      `json:"teamsCount"`
+	MaxTeamsCount int      `json:"maxTeamsCount"`
+	Messages      []string `json:"messages"`
+	// the name and the keys of the groups of the player
+	Name   string   `json:"name"`
+	Groups []string `json:"groups"`
+	// all the players of the session including this one
+	Roster []rosterPlayerState `json:"roster"`
+	// the id to invite other players with
+	GameId     string `json:"gameId"`
+	InviteLink string `json:"inviteLink"`
+}
+
+func writeJson(w http.ResponseWriter, statusCode int, response interface{}) {
+	body, err := json.Marshal(response)
+	if err != nil {
+		log.Println("Error encoding response: ", err)
+		http.Error(w, "Can't encode the response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_, err = w.Write(body)
+	if err != nil {
+		return
+	}
+}
+
+func writeApiError(w http.ResponseWriter, statusCode int, code string, message string) {
+	writeJson(w, statusCode, errorResponse{Error: apiError{Code: code, Message: message}})
+}
+
+func writeOk(w http.ResponseWriter) {
+	writeJson(w, http.StatusOK, okResponse{Ok: true})
+}
+
+func checkMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method != method {
+		writeApiError(w, http.StatusMethodNotAllowed, errorInvalidMethod, "Invalid request method")
+		return false
+	}
+	return true
+}
+
+// reads the JSON body of a POST request, writes an error response on failure
+func decodeRequest(w http.ResponseWriter, r *http.Request, request interface{}) bool {
+	if !checkMethod(w, r, "POST") {
+		return false
+	}
+
+	err := json.NewDecoder(r.Body).Decode(request)
+	if err != nil {
+		writeApiError(w, http.StatusBadRequest, errorInvalidRequest, "Can't parse the request")
+		return false
+	}
+	return true
+}
+
+// finds the session of the player, writes an error response on failure
+func findWebPlayerSession(w http.ResponseWriter, db *database.GameDb, playerToken int64) (userId int64, sessionId int64, isSucceeded bool) {
+	userId, isFound := db.GetWebUserId(playerToken)
+	if !isFound {
+		// the page shows the message and stops updating
+		if message, isRemoved := staticFunctions.GetRemovedWebPlayerMessage(playerToken); isRemoved {
+			writeApiError(w, http.StatusNotFound, errorPlayerNotFound, message)
+		} else {
+			writeApiError(w, http.StatusNotFound, errorPlayerNotFound, "Player not found, has the game ended?")
+		}
+		return
+	}
+
+	sessionId, isInSession := db.GetUserSession(userId)
+	if !isInSession {
+		writeApiError(w, http.StatusNotFound, errorPlayerNotFound, "Player not in session, has the game ended?")
+		return
+	}
+
+	isSucceeded = true
+	return
+}
+
+// reads the request of a player and finds the player's session, writes an error response on failure
+func getWebPlayerSession(w http.ResponseWriter, r *http.Request, db *database.GameDb, request playerTokenRequest) (userId int64, sessionId int64, isSucceeded bool) {
+	if !decodeRequest(w, r, request) {
+		return
+	}
+	return findWebPlayerSession(w, db, request.getPlayerToken())
+}
+
+func getHostWebPlayerSession(w http.ResponseWriter, r *http.Request, db *database.GameDb, request playerTokenRequest) (userId int64, sessionId int64, isSucceeded bool) {
+	userId, sessionId, isSucceeded = getWebPlayerSession(w, r, db, request)
+	if !isSucceeded {
+		return
+	}
+
+	if !db.IsUserSessionHost(userId) {
+		writeApiError(w, http.StatusForbidden, errorNotHost, "Only the host of the game can do this")
+		isSucceeded = false
+	}
+	return
+}
+
+// the GET requests pass the player token in the query, writes an error response on failure
+func getQueryWebPlayerSession(w http.ResponseWriter, r *http.Request, db *database.GameDb) (userId int64, sessionId int64, isSucceeded bool) {
+	if !checkMethod(w, r, "GET") {
+		return
+	}
+
+	playerToken, err := strconv.ParseInt(r.URL.Query().Get("playerToken"), 10, 64)
+	if err != nil {
+		writeApiError(w, http.StatusBadRequest, errorInvalidRequest, "Incorrect player token")
+		return
+	}
+	return findWebPlayerSession(w, db, playerToken)
+}
+
+func getQueryLastMessageIdx(w http.ResponseWriter, r *http.Request) (lastMessageIdx int, isSucceeded bool) {
+	lastMessageIdx, err := strconv.Atoi(r.URL.Query().Get("lastMessageIdx"))
+	if err != nil {
+		writeApiError(w, http.StatusBadRequest, errorInvalidRequest, "Incorrect last message index")
+		return
+	}
+	isSucceeded = true
+	return
+}
+
+// writes an error response if the name can't be used
+func checkPlayerName(w http.ResponseWriter, name string) bool {
+	if name == "" {
+		writeApiError(w, http.StatusBadRequest, errorInvalidRequest, "The name is empty")
+		return false
+	}
+
+	if len(name) > maxPlayerNameLength {
+		writeApiError(w, http.StatusBadRequest, errorInvalidRequest, "The name is too long")
+		return false
+	}
+	return true
+}
+
+// writes an error response if any of the groups is not in the configuration
+func checkGroups(w http.ResponseWriter, staticData *processing.StaticProccessStructs, groups []string) bool {
+	for _, group := range groups {
+		if !staticFunctions.IsGroupAvailable(staticData, group) {
+			writeApiError(w, http.StatusBadRequest, errorInvalidRequest, "Incorrect group")
+			return false
+		}
+	}
+	return true
+}
+
+func joinGame(w http.ResponseWriter, r *http.Request, db *database.GameDb, staticData *processing.StaticProccessStructs) {
+	var request joinRequest
+	if !decodeRequest(w, r, &request) {
+		return
+	}
+
+	if request.GameId == "" {
+		writeApiError(w, http.StatusBadRequest, errorInvalidRequest, "Incorrect game id, reload the page and try again")
+		return
+	}
+
+	sessionId, isFound := db.GetSessionIdFromToken(request.GameId)
+	if !isFound {
+		writeApiError(w, http.StatusNotFound, errorGameNotFound, "Game not found. Was it ended?")
+		return
+	}
+
+	if !checkPlayerName(w, request.Name) || !checkGroups(w, staticData, request.Groups) {
+		return
+	}
+
+	token := int64(rand.Uint64() & 0x7FFFFFFFFFFFFFFF)
+
+	hasAdded := db.AddWebUser(sessionId, token, request.Name, request.Groups)
+
+	if !hasAdded {
+		writeApiError(w, http.StatusInternalServerError, errorInternal, "Can't add new user, try again")
+		return
+	}
+
+	staticFunctions.UpdateSessionDialogs(sessionId, staticData)
+
+	writeJson(w, http.StatusOK, joinResponse{PlayerToken: token})
+}
+
+// the player who creates the game from the web becomes its host, the game doesn't need Telegram players
+func createGame(w http.ResponseWriter, r *http.Request, db *database.GameDb, staticData *processing.StaticProccessStructs) {
+	var request createGameRequest
+	if !decodeRequest(w, r, &request) {
+		return
+	}
+
+	if !checkPlayerName(w, request.Name) || !checkGroups(w, staticData, request.Groups) {
+		return
+	}
+
+	token := int64(rand.Uint64() & 0x7FFFFFFFFFFFFFFF)
+
+	sessionId, hasAdded := db.CreateWebSession(token, request.Name, request.Groups)
+
+	if !hasAdded {
+		writeApiError(w, http.StatusInternalServerError, errorInternal, "Can't create a new game, try again")
+		return
+	}
+
+	gameId, isFound := db.GetTokenFromSessionId(sessionId)
+	if !isFound {
+		log.Printf("Can't find session token for sessionId %d", sessionId)
+	}
+
+	log.Printf("Game %d was created from the web", sessionId)
+
+	writeJson(w, http.StatusOK, createGameResponse{PlayerToken: token, GameId: gameId})
+}
+
+// the groups are shown before the player joins, so their names are in the default language
+func getGroups(w http.ResponseWriter, r *http.Request, staticData *processing.StaticProccessStructs) {
+	if !checkMethod(w, r, "GET") {
+		return
+	}
+
+	trans := staticFunctions.GetDefaultTransFunction(staticData)
+
+	groups := make([]groupResponse, 0)
+	for _, group := range staticFunctions.GetGroups(staticData) {
+		groups = append(groups, groupResponse{
+			Key:  group.Key,
+			Name: staticFunctions.GetGroupName(&group, trans),
+		})
+	}
+
+	writeJson(w, http.StatusOK, groups)
+}
+
+// the state of the session as the player sees it with the messages after lastMessageIdx
+func makeSessionState(db *database.GameDb, staticData *processing.StaticProccessStructs, userId int64, sessionId int64, lastMessageIdx int) (state sessionStateResponse) {
+	messages, newLastIdx := db.GetNewRecentWebMessages(userId, lastMessageIdx)
+
+	state.LastMessageIdx = newLastIdx
+	state.Messages = append(make([]string, 0, len(messages)), messages...)
+
+	trans := staticFunctions.FindTransFunction(userId, staticData)
+
+	state.GameId, _ = db.GetTokenFromSessionId(sessionId)
+	state.InviteLink = staticFunctions.GetInviteLink(staticData, state.GameId)
+	state.Name = db.GetUserName(userId)
+	state.Groups = append(make([]string, 0), db.GetUserGroups(userId)...)
+
+	state.Roster = make([]rosterPlayerState, 0)
+	for _, player := range staticFunctions.GetSessionRoster(staticData, sessionId, trans) {
+		state.Roster = append(state.Roster, rosterPlayerState{
+			UserId:   player.UserId,
+			Name:     player.Name,
+			Groups:   player.Groups,
+			IsHost:   player.IsHost,
+			IsPaused: player.IsPaused,
+			Team:     player.Team,
+		})
+	}
+
+	state.Players = db.GetUsersCountInSession(sessionId, false)
+	state.Suggestions = db.GetSessionSuggestedCommandCount(sessionId)
+	state.Truths = db.GetSessionSuggestedCommandCountInCategory(sessionId, database.TruthCategory)
+	state.Dares = db.GetSessionSuggestedCommandCountInCategory(sessionId, database.DareCategory)
+	state.Penalties = db.GetSessionPenaltyCommandCount(sessionId)
+	state.TruthOrDare = db.IsSessionInTruthOrDareMode(sessionId)
+	state.IsHost = db.IsUserSessionHost(userId)
+
+	kingUserId, isKingFound := staticFunctions.GetSessionKing(staticData, sessionId)
+	if isKingFound {
+		state.King = db.GetUserName(kingUserId)
+	}
+	state.IsKing = isKingFound && kingUserId == userId
+	state.KingMode = db.GetSessionKingMode(sessionId)
+	state.SelectionPolicy = db.GetSessionSelectionPolicy(sessionId)
+
+	if dareId, isFound := db.GetLastPendingRevealedDareId(sessionId); isFound {
+		if dare, isFound := db.GetRevealedDare(dareId); isFound && staticFunctions.CanMarkRevealedDare(staticData, &dare, userId) {
+			state.MarkableDareId = dareId
+		}
+	}
+	state.Penalty = db.GetSessionSkipPenalty(sessionId) > 0
+	state.IsPaused = db.IsUserPaused(userId)
+	state.Paused = staticFunctions.GetPausedPlayerNames(staticData, sessionId)
+
+	state.ContentTags = make([]contentTagState, 0)
+	for _, tag := range staticFunctions.GetContentTagNames(staticData) {
+		state.ContentTags = append(state.ContentTags, contentTagState{
+			Name:     tag,
+			Declined: staticFunctions.IsContentTagDeclined(staticData, userId, tag),
+		})
+	}
+
+	state.Pairs = make([]pairState, 0)
+	pairConstraints := db.GetSessionPairConstraints(sessionId)
+	for _, user := range db.GetUsersInSessionInfo(sessionId) {
+		if user.UserId == userId {
+			continue
+		}
+		state.Pairs = append(state.Pairs, pairState{
+			UserId: user.UserId,
+			Name:   user.Name,
+			Mine:   staticFunctions.GetPairConstraint(pairConstraints, userId, user.UserId),
+			Theirs: staticFunctions.GetPairConstraint(pairConstraints, user.UserId, userId),
+		})
+	}
+
+	state.Team = staticFunctions.GetPlayerTeamName(staticData, sessionId, userId, trans)
+	state.TeamsCount = db.GetSessionTeamsCount(sessionId)
+	state.MaxTeamsCount = staticFunctions.GetMaxTeamsCount(staticData, sessionId)
+	return
+}
+
+func getLastMessages(w http.ResponseWriter, r *http.Request, db *database.GameDb, staticData *processing.StaticProccessStructs) {
+	userId, sessionId, isSucceeded := getQueryWebPlayerSession(w, r, db)
+	if !isSucceeded {
+		return
+	}
+
+	lastMessageIdx, isSucceeded := getQueryLastMessageIdx(w, r)
+	if !isSucceeded {
+		return
+	}
+
+	writeJson(w, http.StatusOK, makeSessionState(db, staticData, userId, sessionId, lastMessageIdx))
+}
+
+// sends the same updates as /messages as Server-Sent Events whenever something changes for the player
+// the stream ends when the player leaves the session, then the page falls back to polling
+func streamUpdates(w http.ResponseWriter, r *http.Request, db *database.GameDb, staticData *processing.StaticProccessStructs) {
+	userId, _, isSucceeded := getQueryWebPlayerSession(w, r, db)
+	if !isSucceeded {
+		return
+	}
+
+	lastMessageIdx, isSucceeded := getQueryLastMessageIdx(w, r)
+	if !isSucceeded {
+		return
+	}
+
+	flusher, isSupported := w.(http.Flusher)
+	if !isSupported {
+		writeApiError(w, http.StatusInternalServerError, errorInternal, "Streaming is not supported")
+		return
+	}
+
+	updates, unsubscribe := staticFunctions.SubscribeToWebUpdates(userId)
+	defer unsubscribe()
+
+	keepAliveTicker := time.NewTicker(streamKeepAliveInterval)
+	defer keepAliveTicker.Stop()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	isUpdated := true
+	for {
+		// the player could be kicked or the session could be ended since the last update
+		sessionId, isInSession := db.GetUserSession(userId)
+		if !isInSession {
+			return
+		}
+
+		var message string
+		if isUpdated {
+			state := makeSessionState(db, staticData, userId, sessionId, lastMessageIdx)
+			lastMessageIdx = state.LastMessageIdx
+
+			update, err := json.Marshal(state)
+			if err != nil {
+				log.Println("Error encoding update: ", err)
+				return
+			}
+			message = "data: " + string(update) + "\n\n"
+		} else {
+			// lets the proxies and the browser know that the connection is still alive
+			message = ": keep-alive\n\n"
+		}
+
+		_, err := w.Write([]byte(message))
+		if err != nil {
+			return
+		}
+		flusher.Flush()
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-updates:
+			isUpdated = true
+		case <-keepAliveTicker.C:
+			isUpdated = false
+		}
+	}
+}
+
+// converts the category of the request: "truth", "dare" or nothing
+func getCategory(category string, defaultCategory int) int {
+	switch category {
+	case "truth":
+		return database.TruthCategory
+	case "dare":
+		return database.DareCategory
+	}
+	return defaultCategory
+}
+
+func suggestCommand(w http.ResponseWriter, r *http.Request, db *database.GameDb, staticData *processing.StaticProccessStructs) {
+	var request suggestRequest
+	userId, sessionId, isSucceeded := getWebPlayerSession(w, r, db, &request)
+	if !isSucceeded {
+		return
+	}
+
+	if request.Command == "" {
+		writeApiError(w, http.StatusBadRequest, errorInvalidRequest, "The command is empty")
+		return
+	}
+
+	if fragment, isFound := staticFunctions.FindInvalidRandomValue(request.Command); isFound {
+		writeApiError(w, http.StatusBadRequest, errorInvalidRequest, staticFunctions.GetInvalidRandomValueMessage(fragment, staticFunctions.FindTransFunction(userId, staticData)))
+		return
+	}
+
+	// penalties go to a separate pool and should name the player who skipped a dare
+	isPenalty := request.Category == "penalty"
+	if isPenalty && !staticFunctions.IsPenaltyValid(staticFunctions.GetSessionPlaceholders(staticData, sessionId), request.Command) {
+		writeApiError(w, http.StatusBadRequest, errorInvalidRequest, staticFunctions.FindTransFunction(userId, staticData)("invalid_penalty"))
+		return
+	}
+
+	if !request.Force {
+		analysis := staticFunctions.AnalyzeDare(staticData, sessionId, request.Command)
+		if !analysis.IsPlayable() {
+			writeApiError(w, http.StatusConflict, errorDareNotPlayable, analysis.GetWarning(staticData, staticFunctions.FindTransFunction(userId, staticData)))
+			return
+		}
+	}
+
+	if isPenalty {
+		db.AddSessionPenaltyCommand(sessionId, request.Command)
+	} else {
+		db.AddSessionSuggestedCommandInCategory(sessionId, request.Command, getCategory(request.Category, database.DareCategory))
+	}
+
+	staticFunctions.UpdateSessionDialogs(sessionId, staticData)
+
+	writeOk(w)
+}
+
+func revealSuggestedCommand(w http.ResponseWriter, r *http.Request, db *database.GameDb, staticData *processing.StaticProccessStructs) {
+	var request revealRequest
+	userId, sessionId, isSucceeded := getWebPlayerSession(w, r, db, &request)
+	if !isSucceeded {
+		return
+	}
+
+	if !staticFunctions.IsUserTurnToReveal(staticData, sessionId, userId) {
+		writeApiError(w, http.StatusForbidden, errorNotYourTurn, staticFunctions.GetNotYourTurnMessage(staticData, sessionId, staticFunctions.FindTransFunction(userId, staticData)))
+		return
+	}
+
+	category := getCategory(request.Category, database.AnyCategory)
+
+	command, isSucceeded := staticFunctions.PopPlayableSuggestedCommand(staticData, sessionId, category)
+	staticFunctions.UpdateSessionDialogs(sessionId, staticData)
+
+	if !isSucceeded {
+		if db.GetSessionSuggestedCommandCountInCategory(sessionId, category) > 0 {
+			writeApiError(w, http.StatusConflict, errorNoPlayableDares, staticFunctions.GetNoPlayableDaresMessage(staticData, sessionId, category, staticFunctions.FindTransFunction(userId, staticData)))
+		} else {
+			writeApiError(w, http.StatusConflict, errorNoDares, "List of commands is empty")
+		}
+		return
+	}
+
+	staticFunctions.SendAdvancedCommand(staticData, sessionId, command)
+
+	writeOk(w)
+}
+
+func leaveGame(w http.ResponseWriter, r *http.Request, db *database.GameDb, staticData *processing.StaticProccessStructs) {
+	var request playerRequest
+	_, sessionId, isSucceeded := getWebPlayerSession(w, r, db, &request)
+	if !isSucceeded {
+		return
+	}
+
+	isSessionAbandoned := db.RemoveWebUser(request.PlayerToken)
+
+	staticFunctions.UpdateLeftSession(staticData, sessionId, isSessionAbandoned)
+
+	writeOk(w)
+}
+
+func renamePlayer(w http.ResponseWriter, r *http.Request, db *database.GameDb, staticData *processing.StaticProccessStructs) {
+	var request renameRequest
+	userId, sessionId, isSucceeded := getWebPlayerSession(w, r, db, &request)
+	if !isSucceeded {
+		return
+	}
+
+	if !checkPlayerName(w, request.Name) {
+		return
+	}
+
+	db.SetUserName(userId, request.Name)
+	staticFunctions.UpdateSessionDialogs(sessionId, staticData)
+
+	writeOk(w)
+}
+
+func changePlayerGroups(w http.ResponseWriter, r *http.Request, db *database.GameDb, staticData *processing.StaticProccessStructs) {
+	var request changeGroupsRequest
+	userId, sessionId, isSucceeded := getWebPlayerSession(w, r, db, &request)
+	if !isSucceeded {
+		return
+	}
+
+	if !checkGroups(w, staticData, request.Groups) {
+		return
+	}
+
+	db.SetUserGroups(userId, request.Groups)
+	staticFunctions.UpdateSessionDialogs(sessionId, staticData)
+
+	writeOk(w)
+}
+
+func sendNumbers(w http.ResponseWriter, r *http.Request, db *database.GameDb, staticData *processing.StaticProccessStructs) {
+	var request playerRequest
+	_, sessionId, isSucceeded := getWebPlayerSession(w, r, db, &request)
+	if !isSucceeded {
+		return
+	}
+
+	staticFunctions.GiveRandomNumbersToPlayers(staticData, sessionId)
+
+	writeOk(w)
+}
+
+func kickPlayer(w http.ResponseWriter, r *http.Request, db *database.GameDb, staticData *processing.StaticProccessStructs) {
+	var request targetPlayerRequest
+	_, sessionId, isSucceeded := getHostWebPlayerSession(w, r, db, &request)
+	if !isSucceeded {
+		return
+	}
+
+	if !staticFunctions.KickPlayer(staticData, sessionId, request.TargetUserId) {
+		writeApiError(w, http.StatusNotFound, errorTargetPlayerNotFound, "The player is not in the game")
+		return
+	}
+
+	writeOk(w)
+}
+
+func transferHost(w http.ResponseWriter, r *http.Request, db *database.GameDb, staticData *processing.StaticProccessStructs) {
+	var request targetPlayerRequest
+	_, sessionId, isSucceeded := getHostWebPlayerSession(w, r, db, &request)
+	if !isSucceeded {
+		return
+	}
+
+	if !staticFunctions.TransferSessionHost(staticData, sessionId, request.TargetUserId) {
+		writeApiError(w, http.StatusNotFound, errorTargetPlayerNotFound, "The player is not in the game")
+		return
+	}
+
+	writeOk(w)
+}
+
+func endGame(w http.ResponseWriter, r *http.Request, db *database.GameDb, staticData *processing.StaticProccessStructs) {
+	var request playerRequest
+	_, sessionId, isSucceeded := getHostWebPlayerSession(w, r, db, &request)
+	if !isSucceeded {
+		return
+	}
+
+	staticFunctions.EndSession(staticData, sessionId)
+
+	writeOk(w)
+}
+
+func setTruthOrDareMode(w http.ResponseWriter, r *http.Request, db *database.GameDb, staticData *processing.StaticProccessStructs) {
+	var request enabledRequest
+	_, sessionId, isSucceeded := getHostWebPlayerSession(w, r, db, &request)
+	if !isSucceeded {
+		return
+	}
+
+	db.SetSessionTruthOrDareMode(sessionId, request.Enabled)
+	staticFunctions.UpdateSessionDialogs(sessionId, staticData)
+
+	writeOk(w)
+}
+
+func setKingMode(w http.ResponseWriter, r *http.Request, db *database.GameDb, staticData *processing.StaticProccessStructs) {
+	var request kingModeRequest
+	userId, sessionId, isSucceeded := getHostWebPlayerSession(w, r, db, &request)
+	if !isSucceeded {
+		return
+	}
+
+	if request.Mode < database.KingModeOff || request.Mode > database.KingModePickedByDare {
+		writeApiError(w, http.StatusBadRequest, errorInvalidRequest, "Incorrect king mode")
+		return
+	}
+
+	staticFunctions.SetSessionKingMode(staticData, sessionId, request.Mode, userId)
+
+	writeOk(w)
+}
+
+func setSelectionPolicy(w http.ResponseWriter, r *http.Request, db *database.GameDb, staticData *processing.StaticProccessStructs) {
+	var request selectionPolicyRequest
+	_, sessionId, isSucceeded := getHostWebPlayerSession(w, r, db, &request)
+	if !isSucceeded {
+		return
+	}
+
+	if request.Policy < database.SelectionPolicyExponential || request.Policy > database.SelectionPolicyRoundRobin {
+		writeApiError(w, http.StatusBadRequest, errorInvalidRequest, "Incorrect selection policy")
+		return
+	}
+
+	db.SetSessionSelectionPolicy(sessionId, request.Policy)
+
+	staticFunctions.UpdateSessionDialogs(sessionId, staticData)
+
+	writeOk(w)
+}
+
+func setTeams(w http.ResponseWriter, r *http.Request, db *database.GameDb, staticData *processing.StaticProccessStructs) {
+	var request teamsRequest
+	_, sessionId, isSucceeded := getHostWebPlayerSession(w, r, db, &request)
+	if !isSucceeded {
+		return
+	}
+
+	if request.Count == 0 {
+		staticFunctions.RemoveSessionTeams(staticData, sessionId)
+	} else if !staticFunctions.SplitSessionIntoTeams(staticData, sessionId, request.Count, request.Balanced) {
+		writeApiError(w, http.StatusConflict, errorNotEnoughPlayers, "Not enough players for this many teams")
+		return
+	}
+
+	writeOk(w)
+}
+
+func setPaused(w http.ResponseWriter, r *http.Request, db *database.GameDb, staticData *processing.StaticProccessStructs) {
+	var request pauseRequest
+	userId, sessionId, isSucceeded := getWebPlayerSession(w, r, db, &request)
+	if !isSucceeded {
+		return
+	}
+
+	db.SetUserPaused(userId, request.Paused)
+	staticFunctions.UpdateSessionDialogs(sessionId, staticData)
+
+	writeOk(w)
+}
+
+func setContentTagDeclined(w http.ResponseWriter, r *http.Request, db *database.GameDb, staticData *processing.StaticProccessStructs) {
+	var request contentTagRequest
+	userId, _, isSucceeded := getWebPlayerSession(w, r, db, &request)
+	if !isSucceeded {
+		return
+	}
+
+	if !staticFunctions.SetContentTagDeclined(staticData, userId, request.Tag, request.Declined) {
+		writeApiError(w, http.StatusBadRequest, errorInvalidRequest, "Unknown content tag")
+		return
+	}
+
+	writeOk(w)
+}
+
+func setPairConstraint(w http.ResponseWriter, r *http.Request, db *database.GameDb, staticData *processing.StaticProccessStructs) {
+	var request pairConstraintRequest
+	userId, sessionId, isSucceeded := getWebPlayerSession(w, r, db, &request)
+	if !isSucceeded {
+		return
+	}
+
+	if !staticFunctions.SetPairConstraint(staticData, sessionId, userId, request.TargetUserId, request.Kind) {
+		writeApiError(w, http.StatusBadRequest, errorInvalidRequest, "Incorrect pairing rule or the player is not in the game")
+		return
+	}
+
+	writeOk(w)
+}
+
+func markDare(w http.ResponseWriter, r *http.Request, db *database.GameDb, staticData *processing.StaticProccessStructs) {
+	var request markDareRequest
+	userId, _, isSucceeded := getWebPlayerSession(w, r, db, &request)
+	if !isSucceeded {
+		return
+	}
+
+	var state int
+	switch request.Result {
+	case "done":
+		state = database.DareStateDone
+	case "skip":
+		state = database.DareStateSkipped
+	default:
+		writeApiError(w, http.StatusBadRequest, errorInvalidRequest, "Incorrect dare result")
+		return
+	}
+
+	result, _ := staticFunctions.MarkRevealedDare(staticData, request.DareId, userId, state)
+	switch result {
+	case staticFunctions.DareNotFound:
+		writeApiError(w, http.StatusNotFound, errorDareNotFound, "Dare not found")
+		return
+	case staticFunctions.DareMarkNotAllowed:
+		writeApiError(w, http.StatusForbidden, errorDareMarkNotAllowed, staticFunctions.GetMarkDareErrorMessage(result, staticFunctions.FindTransFunction(userId, staticData)))
+		return
+	case staticFunctions.DareAlreadyMarked:
+		writeApiError(w, http.StatusConflict, errorDareAlreadyMarked, staticFunctions.GetMarkDareErrorMessage(result, staticFunctions.FindTransFunction(userId, staticData)))
+		return
+	}
+
+	writeOk(w)
+}
+
+func getScore(w http.ResponseWriter, r *http.Request, db *database.GameDb, staticData *processing.StaticProccessStructs) {
+	userId, sessionId, isSucceeded := getQueryWebPlayerSession(w, r, db)
+	if !isSucceeded {
+		return
+	}
+
+	writeJson(w, http.StatusOK, scoreResponse{
+		Score: staticFunctions.GetSessionScoreMessage(staticData, sessionId, staticFunctions.FindTransFunction(userId, staticData)),
+	})
+}
+
+func setSkipPenalty(w http.ResponseWriter, r *http.Request, db *database.GameDb, staticData *processing.StaticProccessStructs) {
+	var request enabledRequest
+	_, sessionId, isSucceeded := getHostWebPlayerSession(w, r, db, &request)
+	if !isSucceeded {
+		return
+	}
+
+	penaltyPoints := 0
+	if request.Enabled {
+		penaltyPoints = staticFunctions.DefaultSkipPenalty
+	}
+
+	db.SetSessionSkipPenalty(sessionId, penaltyPoints)
+	staticFunctions.UpdateSessionDialogs(sessionId, staticData)
+
+	writeOk(w)
+}
+
+func handleApiV1Requests(db *database.GameDb, staticData *processing.StaticProccessStructs) {
+	http.HandleFunc(apiV1Prefix+"join", func(w http.ResponseWriter, r *http.Request) {
+		joinGame(w, r, db, staticData)
+	})
+	http.HandleFunc(apiV1Prefix+"createGame", func(w http.ResponseWriter, r *http.Request) {
+		createGame(w, r, db, staticData)
+	})
+	http.HandleFunc(apiV1Prefix+"groups", func(w http.ResponseWriter, r *http.Request) {
+		getGroups(w, r, staticData)
+	})
+	http.HandleFunc(apiV1Prefix+"messages", func(w http.ResponseWriter, r *http.Request) {
+		getLastMessages(w, r, db, staticData)
+	})
+	http.HandleFunc(apiV1Prefix+"stream", func(w http.ResponseWriter, r *http.Request) {
+		streamUpdates(w, r, db, staticData)
+	})
+	http.HandleFunc(apiV1Prefix+"suggest", func(w http.ResponseWriter, r *http.Request) {
+		suggestCommand(w, r, db, staticData)
+	})
+	http.HandleFunc(apiV1Prefix+"reveal", func(w http.ResponseWriter, r *http.Request) {
+		revealSuggestedCommand(w, r, db, staticData)
+	})
+	http.HandleFunc(apiV1Prefix+"leave", func(w http.ResponseWriter, r *http.Request) {
+		leaveGame(w, r, db, staticData)
+	})
+	http.HandleFunc(apiV1Prefix+"rename", func(w http.ResponseWriter, r *http.Request) {
+		renamePlayer(w, r, db, staticData)
+	})
+	http.HandleFunc(apiV1Prefix+"changeGroups", func(w http.ResponseWriter, r *http.Request) {
+		changePlayerGroups(w, r, db, staticData)
+	})
+	http.HandleFunc(apiV1Prefix+"numbers", func(w http.ResponseWriter, r *http.Request) {
+		sendNumbers(w, r, db, staticData)
+	})
+	http.HandleFunc(apiV1Prefix+"kick", func(w http.ResponseWriter, r *http.Request) {
+		kickPlayer(w, r, db, staticData)
+	})
+	http.HandleFunc(apiV1Prefix+"transferHost", func(w http.ResponseWriter, r *http.Request) {
+		transferHost(w, r, db, staticData)
+	})
+	http.HandleFunc(apiV1Prefix+"truthOrDare", func(w http.ResponseWriter, r *http.Request) {
+		setTruthOrDareMode(w, r, db, staticData)
+	})
+	http.HandleFunc(apiV1Prefix+"kingMode", func(w http.ResponseWriter, r *http.Request) {
+		setKingMode(w, r, db, staticData)
+	})
+	http.HandleFunc(apiV1Prefix+"selectionPolicy", func(w http.ResponseWriter, r *http.Request) {
+		setSelectionPolicy(w, r, db, staticData)
+	})
+	http.HandleFunc(apiV1Prefix+"teams", func(w http.ResponseWriter, r *http.Request) {
+		setTeams(w, r, db, staticData)
+	})
+	http.HandleFunc(apiV1Prefix+"pause", func(w http.ResponseWriter, r *http.Request) {
+		setPaused(w, r, db, staticData)
+	})
+	http.HandleFunc(apiV1Prefix+"declineTag", func(w http.ResponseWriter, r *http.Request) {
+		setContentTagDeclined(w, r, db, staticData)
+	})
+	http.HandleFunc(apiV1Prefix+"pairConstraint", func(w http.ResponseWriter, r *http.Request) {
+		setPairConstraint(w, r, db, staticData)
+	})
+	http.HandleFunc(apiV1Prefix+"markDare", func(w http.ResponseWriter, r *http.Request) {
+		markDare(w, r, db, staticData)
+	})
+	http.HandleFunc(apiV1Prefix+"score", func(w http.ResponseWriter, r *http.Request) {
+		getScore(w, r, db, staticData)
+	})
+	http.HandleFunc(apiV1Prefix+"skipPenalty", func(w http.ResponseWriter, r *http.Request) {
+		setSkipPenalty(w, r, db, staticData)
+	})
+	http.HandleFunc(apiV1Prefix+"endGame", func(w http.ResponseWriter, r *http.Request) {
+		endGame(w, r, db, staticData)
+	})
+	// unknown API methods get a JSON error instead of the home page
+	http.HandleFunc(apiV1Prefix, func(w http.ResponseWriter, r *http.Request) {
+		writeApiError(w, http.StatusNotFound, errorUnknownApiMethod, "Unknown API method")
+	})
+}
@@ -10,10 +10,12 @@ import (
 	"github.com/gameraccoon/telegram-the-king-says-bot/dialogFactories"
 	"github.com/gameraccoon/telegram-the-king-says-bot/httpServer"
 	static "github.com/gameraccoon/telegram-the-king-says-bot/staticData"
+	"github.com/gameraccoon/telegram-the-king-says-bot/staticFunctions"
 	"github.com/nicksnyder/go-i18n/i18n"
 	"io/ioutil"
 	"log"
 	"strings"
+	"time"
 )
 
 func init() {
@@ -40,12 +42,34 @@ func loadConfig(path string) (config static.StaticConfiguration, err error) {
 	}
 
 	if err == nil {
-		jsonString, err := getFileStringContent("./data/placeholders.json")
+		jsonString, err = getFileStringContent("./data/groups.json")
+		if err == nil {
+			dec := json.NewDecoder(strings.NewReader(jsonString))
+			err = dec.Decode(&config.Groups)
+			if err == nil {
+				err = static.ValidateGroups(config.Groups)
+			}
+		}
+	}
+
+	if err == nil {
+		jsonString, err = getFileStringContent("./data/placeholders.json")
 		if err == nil {
 			dec := json.NewDecoder(strings.NewReader(jsonString))
 			err = dec.Decode(&config.Placeholders)
 			if err == nil {
-				config.Placeholders.Compile()
+				err = config.Placeholders.Compile(config.AvailableLanguages, config.Groups)
+			}
+		}
+	}
+
+	if err == nil {
+		jsonString, err = getFileStringContent("./data/contentTags.json")
+		if err == nil {
+			dec := json.NewDecoder(strings.NewReader(jsonString))
+			err = dec.Decode(&config.ContentTags)
+			if err == nil {
+				err = config.ContentTags.Compile()
 			}
 		}
 	}
@@ -109,6 +133,15 @@ func main() {
 	dialogManager.RegisterDialogFactory("se", dialogFactories.MakeSessionDialogFactory())
 	dialogManager.RegisterDialogFactory("ns", dialogFactories.MakeNoSessionDialogFactory())
 	dialogManager.RegisterDialogFactory("sc", dialogFactories.MakeSuggestedConfirmedDialogFactory())
+	dialogManager.RegisterDialogFactory("hp", dialogFactories.MakeHostPlayerSelectDialogFactory())
+	dialogManager.RegisterDialogFactory("pk", dialogFactories.MakeDarePacksDialogFactory())
+	dialogManager.RegisterDialogFactory("dw", dialogFactories.MakeDareWarningDialogFactory())
+	dialogManager.RegisterDialogFactory("km", dialogFactories.MakeKingModeDialogFactory())
+	dialogManager.RegisterDialogFactory("rd", dialogFactories.MakeRevealedDareDialogFactory())
+	dialogManager.RegisterDialogFactory("sp", dialogFactories.MakeSelectionPolicyDialogFactory())
+	dialogManager.RegisterDialogFactory("ct", dialogFactories.MakeContentTagsDialogFactory())
+	dialogManager.RegisterDialogFactory("pc", dialogFactories.MakePairConstraintsDialogFactory())
+	dialogManager.RegisterDialogFactory("tm", dialogFactories.MakeTeamsDialogFactory())
 	dialogManager.RegisterTextInputProcessorManager(dialogFactories.GetTextInputProcessorManager())
 
 	staticData := &processing.StaticProccessStructs{
@@ -124,6 +157,8 @@ func main() {
 
 	staticData.Init()
 
+	go staticFunctions.CleanUpAbandonedSessionsPeriodically(staticData, time.Duration(config.InactiveSessionTimeoutMinutes)*time.Minute)
+
 	if config.RunHttpServer {
 		log.Println("Starting HTTP server")
 		go httpServer.HandleHttpRequests(config.HttpServerPort, staticData)
@@ -4,6 +4,7 @@ import (
 	"github.com/gameraccoon/telegram-bot-skeleton/dialogManager"
 	"github.com/gameraccoon/telegram-bot-skeleton/processing"
 	"github.com/gameraccoon/telegram-the-king-says-bot/staticFunctions"
+	"github.com/go-telegram-bot-api/telegram-bot-api"
 	"strings"
 )
 
@@ -52,6 +53,59 @@ func sendNumbersToPlayers(data *processing.ProcessData) {
 	}
 }
 
+func darePacksCommand(data *processing.ProcessData) {
+	data.SendDialog(data.Static.MakeDialogFn("pk", data.UserId, data.Trans, data.Static, nil))
+}
+
+func saveDarePackCommand(data *processing.ProcessData) {
+	sessionId, isInSession := staticFunctions.GetDb(data.Static).GetUserSession(data.UserId)
+	if !isInSession {
+		data.SendMessage(data.Trans("no_session_error"), true)
+		return
+	}
+
+	if len(data.Message) > 0 {
+		staticFunctions.SaveSessionDarePack(data, sessionId, data.Message)
+	} else {
+		staticFunctions.AskForDarePackName(data, sessionId)
+	}
+}
+
+func exportCommand(data *processing.ProcessData) {
+	sessionId, isInSession := staticFunctions.GetDb(data.Static).GetUserSession(data.UserId)
+	if !isInSession {
+		data.SendMessage(data.Trans("no_session_error"), true)
+		return
+	}
+
+	staticFunctions.SendSessionDaresFile(data, sessionId)
+}
+
+func scoreCommand(data *processing.ProcessData) {
+	sessionId, isInSession := staticFunctions.GetDb(data.Static).GetUserSession(data.UserId)
+	if !isInSession {
+		data.SendMessage(data.Trans("no_session_error"), true)
+		return
+	}
+
+	data.SendMessage(staticFunctions.GetSessionScoreMessage(data.Static, sessionId, data.Trans), true)
+}
+
+func teamsCommand(data *processing.ProcessData) {
+	db := staticFunctions.GetDb(data.Static)
+	if _, isInSession := db.GetUserSession(data.UserId); !isInSession {
+		data.SendMessage(data.Trans("no_session_error"), true)
+		return
+	}
+
+	if !db.IsUserSessionHost(data.UserId) {
+		data.SendMessage(data.Trans("not_session_host"), true)
+		return
+	}
+
+	data.SendDialog(data.Static.MakeDialogFn("tm", data.UserId, data.Trans, data.Static, nil))
+}
+
 func helpCommand(data *processing.ProcessData) {
 	data.SendMessage(data.Trans("help_info"), true)
 }
@@ -69,6 +123,11 @@ func makeUserCommandProcessors() ProcessorFuncMap {
 		"help":     helpCommand,
 		"cancel":   cancelCommand,
 		"numbers":  sendNumbersToPlayers,
+		"packs":    darePacksCommand,
+		"savepack": saveDarePackCommand,
+		"export":   exportCommand,
+		"score":    scoreCommand,
+		"teams":    teamsCommand,
 	}
 }
 
@@ -129,6 +188,12 @@ func processPlainMessage(data *processing.ProcessData, dialogManager *dialogMana
 	}
 }
 
+func processDocument(data *processing.ProcessData, document *tgbotapi.Document) {
+	UpdateProcessData(data)
+
+	staticFunctions.ImportDaresFromDocument(data, document)
+}
+
 func sendSessionOrHelp(data *processing.ProcessData) {
 	_, isInSession := staticFunctions.GetDb(data.Static).GetUserSession(data.UserId)
 	if isInSession {
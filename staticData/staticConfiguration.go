@@ -1,7 +1,10 @@
 package staticData
 
 import (
+	"fmt"
 	cedar "github.com/iohub/ahocorasick"
+	"strconv"
+	"strings"
 )
 
 type LanguageData struct {
@@ -15,10 +18,44 @@ type PlaceholderInfo struct {
 }
 
 type PlaceholderInfos struct {
-	Male     PlaceholderInfo
-	Female   PlaceholderInfo
-	Common   PlaceholderInfo
+	Common PlaceholderInfo
+	// placeholders for players from a participation group, by the key of the group
+	Groups   map[string]*PlaceholderInfo
 	Opposite [2]PlaceholderInfo
+	// the groups of the players named by the opposite placeholders, the order is picked randomly for each dare
+	OppositeGroups [2]string
+	// placeholders for players from the teams, the first one is for the first team
+	Teams []PlaceholderInfo
+	// all the players of a team other than the team of the player named first in the dare
+	OtherTeam PlaceholderInfo
+	// all the players who were not named by other placeholders
+	Others PlaceholderInfo
+	// all the players in the session
+	Everyone PlaceholderInfo
+	// placeholders followed by a number up to this value name the same player within one dare
+	MaxIndex int
+	// placeholders for specific languages, the ones that are not set are taken from the default set
+	Languages map[string]*PlaceholderInfos
+}
+
+// a group that players can choose to participate in, for example a gender
+// the groups are shown to the players in the order they are configured
+type GroupInfo struct {
+	Key string
+	// shown when the translations don't have a "group_<key>" string
+	Name string
+}
+
+type ContentTagInfo struct {
+	Name string
+	// dares that contain any of these words get the tag even without "#name" in them
+	Keywords []string
+}
+
+type ContentTagInfos struct {
+	Tags []ContentTagInfo
+	// the matched value is the index of the tag, nil if there are no tags
+	Matcher *cedar.Matcher
 }
 
 type StaticConfiguration struct {
@@ -26,23 +63,219 @@ type StaticConfiguration struct {
 	DefaultLanguage    string
 	ExtendedLog        bool
 	Placeholders       PlaceholderInfos
+	Groups             []GroupInfo
+	ContentTags        ContentTagInfos
 	RunHttpServer      bool
 	HttpServerPort     int
 	ShareWebAddress    string
+	// sessions without any activity for this time are ended, zero disables it
+	InactiveSessionTimeoutMinutes int
 }
 
-func compilePlaceholder(placeholder *PlaceholderInfo) {
+// the matched value is the index of the placeholder, zero for placeholders without index
+func compilePlaceholder(placeholder *PlaceholderInfo, maxIndex int) {
 	placeholder.Matcher = cedar.NewMatcher()
-	for i, value := range placeholder.Values {
-		placeholder.Matcher.Insert([]byte(value), i)
+	for _, value := range placeholder.Values {
+		placeholder.Matcher.Insert([]byte(value), 0)
+		for index := 1; index <= maxIndex; index++ {
+			placeholder.Matcher.Insert([]byte(value+strconv.Itoa(index)), index)
+		}
 	}
 	placeholder.Matcher.Compile()
 }
 
-func (placeholders *PlaceholderInfos) Compile() {
-	compilePlaceholder(&placeholders.Female)
-	compilePlaceholder(&placeholders.Male)
-	compilePlaceholder(&placeholders.Common)
-	compilePlaceholder(&placeholders.Opposite[0])
-	compilePlaceholder(&placeholders.Opposite[1])
+func inheritPlaceholder(placeholder *PlaceholderInfo, base *PlaceholderInfo) {
+	if len(placeholder.Values) == 0 {
+		placeholder.Values = base.Values
+	}
+}
+
+// takes the placeholders that are not set from the base set
+func (placeholders *PlaceholderInfos) Inherit(base *PlaceholderInfos) {
+	if placeholders.Groups == nil {
+		placeholders.Groups = make(map[string]*PlaceholderInfo)
+	}
+	for key, basePlaceholder := range base.Groups {
+		placeholder, isFound := placeholders.Groups[key]
+		if !isFound {
+			placeholder = &PlaceholderInfo{}
+			placeholders.Groups[key] = placeholder
+		}
+		inheritPlaceholder(placeholder, basePlaceholder)
+	}
+	inheritPlaceholder(&placeholders.Common, &base.Common)
+	// opposite placeholders make sense only as a pair, the partial pairs are rejected by CheckOppositePairs
+	if len(placeholders.Opposite[0].Values) == 0 || len(placeholders.Opposite[1].Values) == 0 {
+		placeholders.Opposite = [2]PlaceholderInfo{{Values: base.Opposite[0].Values}, {Values: base.Opposite[1].Values}}
+	}
+	if len(placeholders.OppositeGroups[0]) == 0 || len(placeholders.OppositeGroups[1]) == 0 {
+		placeholders.OppositeGroups = base.OppositeGroups
+	}
+	for i := range base.Teams {
+		if i >= len(placeholders.Teams) {
+			placeholders.Teams = append(placeholders.Teams, PlaceholderInfo{})
+		}
+		inheritPlaceholder(&placeholders.Teams[i], &base.Teams[i])
+	}
+	inheritPlaceholder(&placeholders.OtherTeam, &base.OtherTeam)
+	inheritPlaceholder(&placeholders.Others, &base.Others)
+	inheritPlaceholder(&placeholders.Everyone, &base.Everyone)
+	if placeholders.MaxIndex == 0 {
+		placeholders.MaxIndex = base.MaxIndex
+	}
+}
+
+func (placeholders *PlaceholderInfos) compileMatchers() {
+	for _, placeholder := range placeholders.Groups {
+		compilePlaceholder(placeholder, placeholders.MaxIndex)
+	}
+	compilePlaceholder(&placeholders.Common, placeholders.MaxIndex)
+	compilePlaceholder(&placeholders.Opposite[0], placeholders.MaxIndex)
+	compilePlaceholder(&placeholders.Opposite[1], placeholders.MaxIndex)
+	for i := range placeholders.Teams {
+		compilePlaceholder(&placeholders.Teams[i], placeholders.MaxIndex)
+	}
+	compilePlaceholder(&placeholders.OtherTeam, placeholders.MaxIndex)
+	compilePlaceholder(&placeholders.Others, 0)
+	compilePlaceholder(&placeholders.Everyone, 0)
+}
+
+func isLanguageAvailable(availableLanguages []LanguageData, language string) bool {
+	for _, lang := range availableLanguages {
+		if lang.Key == language {
+			return true
+		}
+	}
+	return false
+}
+
+func isGroupAvailable(groups []GroupInfo, key string) bool {
+	for _, group := range groups {
+		if group.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// every group gets its placeholders, even if they are empty, and only the known groups can have them
+func (placeholders *PlaceholderInfos) checkGroups(groups []GroupInfo) error {
+	if placeholders.Groups == nil {
+		placeholders.Groups = make(map[string]*PlaceholderInfo)
+	}
+
+	for key := range placeholders.Groups {
+		if !isGroupAvailable(groups, key) {
+			return fmt.Errorf("placeholders are set for group %s that is not in the groups list", key)
+		}
+	}
+
+	for _, group := range groups {
+		if _, isFound := placeholders.Groups[group.Key]; !isFound {
+			placeholders.Groups[group.Key] = &PlaceholderInfo{}
+		}
+	}
+
+	if !isGroupAvailable(groups, placeholders.OppositeGroups[0]) || !isGroupAvailable(groups, placeholders.OppositeGroups[1]) || placeholders.OppositeGroups[0] == placeholders.OppositeGroups[1] {
+		return fmt.Errorf("opposite placeholders should name two different groups from the groups list")
+	}
+	return nil
+}
+
+// the sets that inherit the opposite placeholders or groups should set either both or none of them
+func (placeholders *PlaceholderInfos) CheckOppositePairs() error {
+	if (len(placeholders.Opposite[0].Values) == 0) != (len(placeholders.Opposite[1].Values) == 0) {
+		return fmt.Errorf("opposite placeholders should be set as a pair")
+	}
+	if (len(placeholders.OppositeGroups[0]) == 0) != (len(placeholders.OppositeGroups[1]) == 0) {
+		return fmt.Errorf("opposite groups should be set as a pair")
+	}
+	return nil
+}
+
+// groups can be nil for the sets that inherited their groups from an already checked set
+func (placeholders *PlaceholderInfos) Compile(availableLanguages []LanguageData, groups []GroupInfo) error {
+	if groups != nil {
+		if err := placeholders.checkGroups(groups); err != nil {
+			return err
+		}
+	}
+	placeholders.compileMatchers()
+
+	for language, languagePlaceholders := range placeholders.Languages {
+		if !isLanguageAvailable(availableLanguages, language) {
+			return fmt.Errorf("placeholders are set for language %s that is not in the available languages", language)
+		}
+		if err := languagePlaceholders.CheckOppositePairs(); err != nil {
+			return fmt.Errorf("placeholders for language %s: %s", language, err.Error())
+		}
+		languagePlaceholders.Inherit(placeholders)
+		if groups != nil {
+			if err := languagePlaceholders.checkGroups(groups); err != nil {
+				return err
+			}
+		}
+		languagePlaceholders.compileMatchers()
+	}
+	return nil
+}
+
+// the keys are stored separated by commas and passed in dialog callbacks that are split by underscores
+func validateKey(kind string, key string) error {
+	if len(key) == 0 || strings.ContainsAny(key, ", _") {
+		return fmt.Errorf("%s \"%s\" should not be empty and should not contain commas, spaces or underscores", kind, key)
+	}
+	return nil
+}
+
+func ValidateGroups(groups []GroupInfo) error {
+	if len(groups) == 0 {
+		return fmt.Errorf("at least one participation group should be set")
+	}
+
+	for i, group := range groups {
+		if err := validateKey("group key", group.Key); err != nil {
+			return err
+		}
+		if isGroupAvailable(groups[:i], group.Key) {
+			return fmt.Errorf("group %s is set twice", group.Key)
+		}
+	}
+	return nil
+}
+
+// the tags and keywords are matched in lower case
+func (contentTags *ContentTagInfos) Compile() error {
+	if len(contentTags.Tags) == 0 {
+		return nil
+	}
+
+	names := make(map[string]bool)
+	contentTags.Matcher = cedar.NewMatcher()
+	for index := range contentTags.Tags {
+		tag := &contentTags.Tags[index]
+		tag.Name = strings.ToLower(tag.Name)
+		if err := validateKey("content tag name", tag.Name); err != nil {
+			return err
+		}
+		if names[tag.Name] {
+			return fmt.Errorf("content tag %s is set twice", tag.Name)
+		}
+		names[tag.Name] = true
+
+		contentTags.Matcher.Insert([]byte("#"+tag.Name), index)
+		for _, keyword := range tag.Keywords {
+			contentTags.Matcher.Insert([]byte(strings.ToLower(keyword)), index)
+		}
+	}
+	contentTags.Matcher.Compile()
+	return nil
+}
+
+// returns the placeholders for the language or the default ones if the language doesn't have its own
+func (placeholders *PlaceholderInfos) ForLanguage(language string) *PlaceholderInfos {
+	if languagePlaceholders, isFound := placeholders.Languages[language]; isFound {
+		return languagePlaceholders
+	}
+	return placeholders
 }
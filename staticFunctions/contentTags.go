@@ -0,0 +1,102 @@
+package staticFunctions
+
+import (
+	"github.com/gameraccoon/telegram-bot-skeleton/processing"
+	"github.com/gameraccoon/telegram-the-king-says-bot/database"
+	static "github.com/gameraccoon/telegram-the-king-says-bot/staticData"
+	"strings"
+)
+
+func getContentTags(staticData *processing.StaticProccessStructs) *static.ContentTagInfos {
+	config, configCastSuccess := staticData.Config.(static.StaticConfiguration)
+
+	if !configCastSuccess {
+		config = static.StaticConfiguration{}
+	}
+
+	return &config.ContentTags
+}
+
+// returns the names of all the tags the players can decline in the order they are configured
+func GetContentTagNames(staticData *processing.StaticProccessStructs) (names []string) {
+	for _, tag := range getContentTags(staticData).Tags {
+		names = append(names, tag.Name)
+	}
+	return
+}
+
+// the dare has a tag if it is written as "#tag" or if the dare contains any of its keywords
+func findCommandTags(contentTags *static.ContentTagInfos, command string) (tags []string) {
+	if contentTags.Matcher == nil {
+		return
+	}
+
+	sequence := []byte(strings.ToLower(command))
+	resp := contentTags.Matcher.Match(sequence)
+	defer resp.Release()
+
+	for resp.HasNext() {
+		for _, itr := range resp.NextMatchItem(sequence) {
+			index, _ := itr.Value.(int)
+			if !containsString(tags, contentTags.Tags[index].Name) {
+				tags = append(tags, contentTags.Tags[index].Name)
+			}
+		}
+	}
+	return
+}
+
+func containsString(slice []string, val string) bool {
+	for _, item := range slice {
+		if item == val {
+			return true
+		}
+	}
+	return false
+}
+
+func isAcceptingTags(user *database.SessionUserInfo, tags []string) bool {
+	for _, tag := range tags {
+		if containsString(user.DeclinedTags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// the players who didn't decline any of the tags
+func getUsersAcceptingTags(users []database.SessionUserInfo, tags []string) (acceptingUsers []database.SessionUserInfo) {
+	for i := range users {
+		if isAcceptingTags(&users[i], tags) {
+			acceptingUsers = append(acceptingUsers, users[i])
+		}
+	}
+	return
+}
+
+func IsContentTagDeclined(staticData *processing.StaticProccessStructs, userId int64, tag string) bool {
+	return containsString(GetDb(staticData).GetUserDeclinedTags(userId), tag)
+}
+
+// returns false if there is no such tag in the configuration
+func SetContentTagDeclined(staticData *processing.StaticProccessStructs, userId int64, tag string, isDeclined bool) bool {
+	if !containsString(GetContentTagNames(staticData), tag) {
+		return false
+	}
+
+	db := GetDb(staticData)
+
+	declinedTags := make([]string, 0)
+	for _, declinedTag := range db.GetUserDeclinedTags(userId) {
+		if declinedTag != tag {
+			declinedTags = append(declinedTags, declinedTag)
+		}
+	}
+
+	if isDeclined {
+		declinedTags = append(declinedTags, tag)
+	}
+
+	db.SetUserDeclinedTags(userId, declinedTags)
+	return true
+}
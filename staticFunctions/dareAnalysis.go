@@ -0,0 +1,213 @@
+package staticFunctions
+
+import (
+	"github.com/gameraccoon/telegram-bot-skeleton/processing"
+	"github.com/gameraccoon/telegram-the-king-says-bot/database"
+	static "github.com/gameraccoon/telegram-the-king-says-bot/staticData"
+	"github.com/nicksnyder/go-i18n/i18n"
+	"sort"
+	"strings"
+)
+
+type playersCount struct {
+	// the players by the keys of the groups
+	groups map[string]int
+	// the players by their teams
+	teams map[int]int
+	total int
+}
+
+type DareAnalysis struct {
+	required  playersCount
+	available playersCount
+	// whether every placeholder with a group or a team can get its own player
+	canFillRestrictedMatches bool
+}
+
+func countRequiredPlayers(matches []placeholderMatch) (count playersCount) {
+	count.groups = make(map[string]int)
+	count.teams = make(map[int]int)
+	countedBindings := make(map[placeholderBinding]bool)
+	for _, match := range matches {
+		if match.kind != singlePlayerMatch {
+			continue
+		}
+
+		if isBound(match.binding) {
+			if countedBindings[match.binding] {
+				continue
+			}
+			countedBindings[match.binding] = true
+		}
+
+		if len(match.group) > 0 {
+			count.groups[match.group]++
+		}
+		if match.team != 0 {
+			count.teams[match.team]++
+		}
+		count.total++
+	}
+	return
+}
+
+// the players who can be named in a dare with the tags
+func getAvailablePlayers(users []database.SessionUserInfo, tags []string) []database.SessionUserInfo {
+	return getUsersAcceptingTags(getActiveUsers(users), tags)
+}
+
+func countAvailablePlayers(users []database.SessionUserInfo) (count playersCount) {
+	count.groups = make(map[string]int)
+	count.teams = make(map[int]int)
+	for _, user := range users {
+		for _, group := range user.Groups {
+			count.groups[group]++
+		}
+		if user.Team != 0 {
+			count.teams[user.Team]++
+		}
+		count.total++
+	}
+	return
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// counts the players the dare names and compares them with the players in the session
+func AnalyzeDare(staticData *processing.StaticProccessStructs, sessionId int64, command string) DareAnalysis {
+	tags := findCommandTags(getContentTags(staticData), command)
+	available := getAvailablePlayers(GetDb(staticData).GetUsersInSessionInfo(sessionId), tags)
+	return analyzeDareForPlayers(GetSessionPlaceholders(staticData, sessionId), command, available)
+}
+
+func analyzeDareForPlayers(placeholders *static.PlaceholderInfos, command string, availableUsers []database.SessionUserInfo) (analysis DareAnalysis) {
+	sequence := []byte(command)
+
+	// the order of the opposite groups is picked randomly on reveal, so the dare should fit both ways
+	oppositeGroups := placeholders.OppositeGroups
+	matches1 := findMatchesWithOppositeGroups(placeholders, sequence, oppositeGroups)
+	matches2 := findMatchesWithOppositeGroups(placeholders, sequence, [2]string{oppositeGroups[1], oppositeGroups[0]})
+	required1 := countRequiredPlayers(matches1)
+	required2 := countRequiredPlayers(matches2)
+
+	analysis.required = required1
+	for group, count := range required2.groups {
+		analysis.required.groups[group] = maxInt(required1.groups[group], count)
+	}
+
+	analysis.available = countAvailablePlayers(availableUsers)
+
+	// the players are matched the same way as on reveal, so a player in several groups is counted only once
+	_, isComplete1 := assignPlayersToMatches(getRestrictedMatchesToFill(matches1, nil), availableUsers)
+	_, isComplete2 := assignPlayersToMatches(getRestrictedMatchesToFill(matches2, nil), availableUsers)
+	analysis.canFillRestrictedMatches = isComplete1 && isComplete2
+	return
+}
+
+func (analysis *DareAnalysis) IsPlayable() bool {
+	return analysis.canFillRestrictedMatches && analysis.required.total <= analysis.available.total
+}
+
+// returns the sorted teams that have players in any of the counts
+func getCountedTeams(counts ...*playersCount) (teams []int) {
+	for _, count := range counts {
+		for team := range count.teams {
+			if !containsInt(teams, team) {
+				teams = append(teams, team)
+			}
+		}
+	}
+	sort.Ints(teams)
+	return
+}
+
+func containsInt(slice []int, val int) bool {
+	for _, item := range slice {
+		if item == val {
+			return true
+		}
+	}
+	return false
+}
+
+// lists the players of every configured group and of the given teams like "Girl: 2, Boy: 1, Team 1: 2"
+func formatGroupCounts(staticData *processing.StaticProccessStructs, count *playersCount, teams []int, trans i18n.TranslateFunc) string {
+	groups := GetGroups(staticData)
+	counts := make([]string, 0, len(groups)+len(teams))
+	for i := range groups {
+		counts = append(counts, trans("group_count", map[string]interface{}{
+			"Group": GetGroupName(&groups[i], trans),
+			"Count": count.groups[groups[i].Key],
+		}))
+	}
+	for _, team := range teams {
+		counts = append(counts, trans("group_count", map[string]interface{}{
+			"Group": trans("team_name", map[string]interface{}{"Team": team}),
+			"Count": count.teams[team],
+		}))
+	}
+	return strings.Join(counts, ", ")
+}
+
+func (analysis *DareAnalysis) GetWarning(staticData *processing.StaticProccessStructs, trans i18n.TranslateFunc) string {
+	teams := getCountedTeams(&analysis.required, &analysis.available)
+	return trans("dare_does_not_fit", map[string]interface{}{
+		"Required":        analysis.required.total,
+		"RequiredGroups":  formatGroupCounts(staticData, &analysis.required, teams, trans),
+		"Available":       analysis.available.total,
+		"AvailableGroups": formatGroupCounts(staticData, &analysis.available, teams, trans),
+	})
+}
+
+// pops a random dare that can be played by the players currently in the session
+// the dares that need more players stay in the queue
+func PopPlayableSuggestedCommand(staticData *processing.StaticProccessStructs, sessionId int64, category int) (command string, isSucceeded bool) {
+	db := GetDb(staticData)
+	users := db.GetUsersInSessionInfo(sessionId)
+	placeholders := GetSessionPlaceholders(staticData, sessionId)
+	contentTags := getContentTags(staticData)
+
+	return db.PopRandomMatchingSessionSuggestedCommand(sessionId, category, func(command string) bool {
+		available := getAvailablePlayers(users, findCommandTags(contentTags, command))
+		analysis := analyzeDareForPlayers(placeholders, command, available)
+		return analysis.IsPlayable()
+	})
+}
+
+func GetNoPlayableDaresMessage(staticData *processing.StaticProccessStructs, sessionId int64, category int, trans i18n.TranslateFunc) string {
+	db := GetDb(staticData)
+	available := countAvailablePlayers(getAvailablePlayers(db.GetUsersInSessionInfo(sessionId), nil))
+
+	return trans("no_playable_dares", map[string]interface{}{
+		"Count":           db.GetSessionSuggestedCommandCountInCategory(sessionId, category),
+		"Available":       available.total,
+		"AvailableGroups": formatGroupCounts(staticData, &available, getCountedTeams(&available), trans),
+	})
+}
+
+// only the penalties that can name the penalized player in their first placeholder are drawn
+func GetPlayablePenaltyCommand(staticData *processing.StaticProccessStructs, sessionId int64, penalizedUserId int64) (command string, isFound bool) {
+	db := GetDb(staticData)
+	users := db.GetUsersInSessionInfo(sessionId)
+	placeholders := GetSessionPlaceholders(staticData, sessionId)
+	contentTags := getContentTags(staticData)
+
+	penalizedUser, isFound := findUserInfo(users, penalizedUserId)
+	if !isFound {
+		return
+	}
+
+	return db.GetRandomMatchingSessionPenaltyCommand(sessionId, func(command string) bool {
+		if !canTakePenalty(placeholders, contentTags, command, &penalizedUser) {
+			return false
+		}
+		available := getAvailablePlayers(users, findCommandTags(contentTags, command))
+		analysis := analyzeDareForPlayers(placeholders, command, available)
+		return analysis.IsPlayable()
+	})
+}
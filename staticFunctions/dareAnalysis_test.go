@@ -0,0 +1,63 @@
+package staticFunctions
+
+import (
+	"github.com/gameraccoon/telegram-the-king-says-bot/database"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestIsPlayableWithPlayersInSeveralGroups(t *testing.T) {
+	placeholders := makeTestPlaceholders(t)
+
+	bothGroups1 := database.SessionUserInfo{UserId: 1, Name: "both1", Groups: []string{"female", "male"}}
+	bothGroups2 := database.SessionUserInfo{UserId: 2, Name: "both2", Groups: []string{"female", "male"}}
+	girl := database.SessionUserInfo{UserId: 3, Name: "girl", Groups: []string{"female"}}
+	nonbinary := database.SessionUserInfo{UserId: 4, Name: "nonbinary", Groups: []string{"nonbinary"}}
+
+	testCases := []struct {
+		name       string
+		command    string
+		users      []database.SessionUserInfo
+		isPlayable bool
+	}{
+		{"one of each group", "🚹 kisses 🚺", []database.SessionUserInfo{bothGroups1, girl}, true},
+		{"one of each group in another order", "🚺 kisses 🚹", []database.SessionUserInfo{bothGroups1, girl}, true},
+		{"one player for two groups", "🚹 kisses 🚺", []database.SessionUserInfo{bothGroups1, nonbinary}, false},
+		// there are enough players in each group and enough players in groups, but not at the same time
+		{"three placeholders for two players", "🚺 and 🚺 kiss 🚹", []database.SessionUserInfo{bothGroups1, bothGroups2, nonbinary}, false},
+		{"three placeholders for three players", "🚺 and 🚺 kiss 🚹", []database.SessionUserInfo{bothGroups1, bothGroups2, girl}, true},
+		{"opposite placeholders", "❤️ kisses 💙", []database.SessionUserInfo{bothGroups1, girl}, true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			analysis := analyzeDareForPlayers(placeholders, testCase.command, testCase.users)
+			require.Equal(t, testCase.isPlayable, analysis.IsPlayable())
+		})
+	}
+}
+
+func TestFillRestrictedMatchesWithPlayersInSeveralGroups(t *testing.T) {
+	assert := require.New(t)
+	placeholders := makeTestPlaceholders(t)
+
+	bothGroups := database.SessionUserInfo{UserId: 1, Name: "both", Groups: []string{"female", "male"}}
+	girl := database.SessionUserInfo{UserId: 2, Name: "girl", Groups: []string{"female"}}
+
+	for _, command := range []string{"🚹 kisses 🚺", "🚺 kisses 🚹"} {
+		// the player in both groups goes first, so taking the first player who fits would leave 🚹 without a player
+		users := []database.SessionUserInfo{bothGroups, girl}
+		matches := findMatches(placeholders, []byte(command))
+
+		fillRestrictedMatches(matches, &users, make(map[placeholderBinding]*placeholderMatch))
+
+		assert.Equal(0, len(users))
+		for _, match := range matches {
+			if match.group == "male" {
+				assert.Equal(bothGroups.UserId, match.userId)
+			} else {
+				assert.Equal(girl.UserId, match.userId)
+			}
+		}
+	}
+}
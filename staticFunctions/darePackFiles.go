@@ -0,0 +1,295 @@
+package staticFunctions
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gameraccoon/telegram-bot-skeleton/processing"
+	"github.com/gameraccoon/telegram-bot-skeleton/telegramChat"
+	"github.com/gameraccoon/telegram-the-king-says-bot/database"
+	static "github.com/gameraccoon/telegram-the-king-says-bot/staticData"
+	"github.com/go-telegram-bot-api/telegram-bot-api"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	maxDarePackFileSize   = 1024 * 1024
+	maxDaresInFile        = 1000
+	maxDarePackNameLength = 30
+)
+
+type darePackFile struct {
+	Name     string   `json:"name,omitempty"`
+	Language string   `json:"language,omitempty"`
+	Commands []string `json:"commands"`
+	Truths   []string `json:"truths,omitempty"`
+}
+
+// JSON files contain either a list of dares or an object with "name", "language", "commands" and "truths" fields
+func parseDarePackJson(content []byte) (pack darePackFile, err error) {
+	var commands []string
+	if json.Unmarshal(content, &commands) == nil {
+		pack.Commands = commands
+		return
+	}
+
+	err = json.Unmarshal(content, &pack)
+	return
+}
+
+// CSV files have a dare in the first column, an optional language tag in the second column
+// and an optional "truth" or "dare" category in the third column
+func parseDarePackCsv(content []byte) (pack darePackFile, err error) {
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return
+	}
+
+	for i, record := range records {
+		if len(record) == 0 {
+			continue
+		}
+
+		// skip the header if there is one
+		if i == 0 && strings.EqualFold(strings.TrimSpace(record[0]), "command") {
+			continue
+		}
+
+		if len(record) > 2 && strings.EqualFold(strings.TrimSpace(record[2]), "truth") {
+			pack.Truths = append(pack.Truths, record[0])
+		} else {
+			pack.Commands = append(pack.Commands, record[0])
+		}
+		if len(record) > 1 && pack.Language == "" {
+			pack.Language = strings.TrimSpace(record[1])
+		}
+	}
+	return
+}
+
+func parseDarePackFile(fileName string, content []byte) (pack darePackFile, err error) {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".json":
+		pack, err = parseDarePackJson(content)
+	case ".csv":
+		pack, err = parseDarePackCsv(content)
+	default:
+		err = errors.New("unsupported file type")
+	}
+
+	if err == nil && pack.Name == "" {
+		pack.Name = strings.TrimSuffix(filepath.Base(fileName), filepath.Ext(fileName))
+	}
+	return
+}
+
+func (pack *darePackFile) getCommands() (commands []database.CategorizedCommand) {
+	for _, command := range pack.Commands {
+		commands = append(commands, database.CategorizedCommand{Command: command, Category: database.DareCategory})
+	}
+	for _, command := range pack.Truths {
+		commands = append(commands, database.CategorizedCommand{Command: command, Category: database.TruthCategory})
+	}
+	return
+}
+
+func filterValidCommands(commands []database.CategorizedCommand) (validCommands []database.CategorizedCommand, skippedCount int) {
+	for _, command := range commands {
+		command.Command = strings.TrimSpace(command.Command)
+		if len(validCommands) < maxDaresInFile && IsCommandValid(command.Command) {
+			validCommands = append(validCommands, command)
+		} else {
+			skippedCount++
+		}
+	}
+	return
+}
+
+func getAvailableLanguage(staticData *processing.StaticProccessStructs, language string) string {
+	config, configCastSuccess := staticData.Config.(static.StaticConfiguration)
+
+	if !configCastSuccess {
+		config = static.StaticConfiguration{}
+	}
+
+	language = strings.ToLower(language)
+	for _, lang := range config.AvailableLanguages {
+		if lang.Key == language {
+			return lang.Key
+		}
+	}
+	return ""
+}
+
+func getTelegramChat(staticData *processing.StaticProccessStructs) *telegramChat.TelegramChat {
+	chat, ok := staticData.Chat.(*telegramChat.TelegramChat)
+	if !ok || chat == nil {
+		log.Fatal("chat is not set properly")
+		return nil
+	}
+	return chat
+}
+
+func downloadTelegramFile(staticData *processing.StaticProccessStructs, fileId string) (content []byte, err error) {
+	chat := getTelegramChat(staticData)
+
+	chat.LockMutex()
+	url, err := chat.GetBot().GetFileDirectURL(fileId)
+	chat.UnlockMutex()
+	if err != nil {
+		return
+	}
+
+	response, err := http.Get(url)
+	if err != nil {
+		return
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		err = fmt.Errorf("unexpected status code %d", response.StatusCode)
+		return
+	}
+
+	return io.ReadAll(io.LimitReader(response.Body, maxDarePackFileSize))
+}
+
+func SendDocument(staticData *processing.StaticProccessStructs, chatId int64, fileName string, content []byte) {
+	chat := getTelegramChat(staticData)
+
+	document := tgbotapi.NewDocumentUpload(chatId, tgbotapi.FileBytes{
+		Name:  fileName,
+		Bytes: content,
+	})
+
+	chat.LockMutex()
+	_, err := chat.GetBot().Send(document)
+	chat.UnlockMutex()
+
+	if err != nil {
+		log.Printf("Can't send document to chat %d: %s", chatId, err.Error())
+	}
+}
+
+func makeDarePackFile(name string, language string, commands []database.CategorizedCommand) []byte {
+	pack := darePackFile{
+		Name:     name,
+		Language: language,
+		Commands: []string{},
+	}
+
+	for _, command := range commands {
+		if command.Category == database.TruthCategory {
+			pack.Truths = append(pack.Truths, command.Command)
+		} else {
+			pack.Commands = append(pack.Commands, command.Command)
+		}
+	}
+
+	content, err := json.MarshalIndent(pack, "", "\t")
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	return content
+}
+
+func SendSessionDaresFile(data *processing.ProcessData, sessionId int64) {
+	commands := GetDb(data.Static).GetSessionSuggestedCommands(sessionId)
+	if len(commands) == 0 {
+		data.SendMessage(data.Trans("no_dares_to_save"), true)
+		return
+	}
+
+	SendDocument(data.Static, data.ChatId, "dares.json", makeDarePackFile("", "", commands))
+}
+
+func SendDarePackFile(data *processing.ProcessData, packId int64) {
+	pack, isFound := FindUserDarePack(data.Static, data.UserId, packId)
+	if !isFound {
+		data.SendMessage(data.Trans("dare_pack_not_found"), true)
+		return
+	}
+
+	commands, _ := GetDb(data.Static).GetDarePackCommands(packId, data.UserId)
+	SendDocument(data.Static, data.ChatId, pack.Name+".json", makeDarePackFile(pack.Name, pack.Language, commands))
+}
+
+func StartDarePackImport(data *processing.ProcessData) {
+	data.SendMessage(data.Trans("send_dare_pack_file"), true)
+	data.Static.SetUserStateTextProcessor(data.UserId, &processing.AwaitingTextProcessorData{
+		ProcessorId: "importDarePack",
+	})
+}
+
+// imports dares from a document sent by the user, the dares go to a new pack if the user
+// started the import from the pack list, or to the current session otherwise
+func ImportDaresFromDocument(data *processing.ProcessData, document *tgbotapi.Document) {
+	db := GetDb(data.Static)
+
+	textProcessor := data.Static.GetUserStateTextProcessor(data.UserId)
+	isImportingPack := textProcessor != nil && textProcessor.ProcessorId == "importDarePack"
+
+	sessionId, isInSession := db.GetUserSession(data.UserId)
+	if !isImportingPack && !isInSession {
+		data.SendMessage(data.Trans("dare_file_no_target"), true)
+		return
+	}
+
+	if document.FileSize > maxDarePackFileSize {
+		data.SendMessage(data.Trans("dare_file_too_big"), true)
+		return
+	}
+
+	content, err := downloadTelegramFile(data.Static, document.FileID)
+	if err != nil {
+		log.Printf("Can't download file from user %d: %s", data.UserId, err.Error())
+		data.SendMessage(data.Trans("dare_file_download_failed"), true)
+		return
+	}
+
+	pack, err := parseDarePackFile(document.FileName, content)
+	if err != nil {
+		data.SendMessage(data.Trans("dare_file_invalid"), true)
+		return
+	}
+
+	commands, skippedCount := filterValidCommands(pack.getCommands())
+	if len(commands) == 0 {
+		data.SendMessage(data.Trans("no_valid_dares_in_file", map[string]interface{}{
+			"Skipped": skippedCount,
+		}), true)
+		return
+	}
+
+	if isImportingPack {
+		name := pack.Name
+		if nameRunes := []rune(name); len(nameRunes) > maxDarePackNameLength {
+			name = string(nameRunes[:maxDarePackNameLength])
+		}
+
+		db.CreateDarePack(data.UserId, name, getAvailableLanguage(data.Static, pack.Language), commands)
+		data.Static.SetUserStateTextProcessor(data.UserId, nil)
+		data.SendMessage(data.Trans("dare_pack_imported", map[string]interface{}{
+			"Name":    name,
+			"Count":   len(commands),
+			"Skipped": skippedCount,
+		}), true)
+	} else {
+		db.AddSessionSuggestedCommands(sessionId, commands)
+		UpdateSessionDialogs(sessionId, data.Static)
+		data.SendMessage(data.Trans("dares_imported", map[string]interface{}{
+			"Count":   len(commands),
+			"Skipped": skippedCount,
+		}), true)
+	}
+}
@@ -0,0 +1,58 @@
+package staticFunctions
+
+import (
+	"github.com/gameraccoon/telegram-bot-skeleton/processing"
+	"github.com/gameraccoon/telegram-the-king-says-bot/database"
+	"unicode/utf8"
+)
+
+func FindUserDarePack(staticData *processing.StaticProccessStructs, userId int64, packId int64) (pack database.DarePackInfo, isFound bool) {
+	for _, pack := range GetDb(staticData).GetUserDarePacks(userId) {
+		if pack.Id == packId {
+			return pack, true
+		}
+	}
+	return
+}
+
+func LoadDarePackToSession(staticData *processing.StaticProccessStructs, userId int64, sessionId int64, packId int64) (addedCount int, isSucceeded bool) {
+	db := GetDb(staticData)
+	commands, isFound := db.GetDarePackCommands(packId, userId)
+	if !isFound {
+		return
+	}
+
+	db.AddSessionSuggestedCommands(sessionId, commands)
+
+	UpdateSessionDialogs(sessionId, staticData)
+	return len(commands), true
+}
+
+func AskForDarePackName(data *processing.ProcessData, sessionId int64) {
+	data.SendMessage(data.Trans("enter_dare_pack_name"), true)
+	data.Static.SetUserStateTextProcessor(data.UserId, &processing.AwaitingTextProcessorData{
+		ProcessorId:  "darePackName",
+		AdditionalId: sessionId,
+	})
+}
+
+func SaveSessionDarePack(data *processing.ProcessData, sessionId int64, name string) {
+	db := GetDb(data.Static)
+
+	if utf8.RuneCountInString(name) > maxDarePackNameLength {
+		data.SendMessage(data.Trans("dare_pack_name_too_long"), true)
+		return
+	}
+
+	commandsCount := db.GetSessionSuggestedCommandCount(sessionId)
+	if commandsCount == 0 {
+		data.SendMessage(data.Trans("no_dares_to_save"), true)
+		return
+	}
+
+	db.SaveSessionCommandsAsPack(sessionId, data.UserId, name)
+	data.SendMessage(data.Trans("dare_pack_saved", map[string]interface{}{
+		"Name":  name,
+		"Count": commandsCount,
+	}), true)
+}
@@ -0,0 +1,163 @@
+package staticFunctions
+
+import (
+	"github.com/gameraccoon/telegram-bot-skeleton/processing"
+	"github.com/gameraccoon/telegram-the-king-says-bot/database"
+	"github.com/nicksnyder/go-i18n/i18n"
+	"strings"
+)
+
+// penalty points for a skipped dare when the host enables them
+const DefaultSkipPenalty = 1
+
+const (
+	DareMarked = iota
+	DareNotFound
+	DareMarkNotAllowed
+	DareAlreadyMarked
+)
+
+func findDarePlayer(dare *database.RevealedDareInfo, userId int64) (player database.RevealedDarePlayerInfo, isFound bool) {
+	for _, player := range dare.Players {
+		if player.UserId == userId {
+			return player, true
+		}
+	}
+	return
+}
+
+func hasPendingPlayers(dare *database.RevealedDareInfo) bool {
+	for _, player := range dare.Players {
+		if player.State == database.DareStatePending {
+			return true
+		}
+	}
+	return false
+}
+
+// the named players mark their own part of the dare, the host can mark it for everyone
+func CanMarkRevealedDare(staticData *processing.StaticProccessStructs, dare *database.RevealedDareInfo, userId int64) bool {
+	db := GetDb(staticData)
+
+	if sessionId, isInSession := db.GetUserSession(userId); !isInSession || sessionId != dare.SessionId {
+		return false
+	}
+
+	if db.IsUserSessionHost(userId) {
+		return hasPendingPlayers(dare)
+	}
+
+	player, isFound := findDarePlayer(dare, userId)
+	return isFound && player.State == database.DareStatePending
+}
+
+// returns the players whose state was changed
+func MarkRevealedDare(staticData *processing.StaticProccessStructs, dareId int64, userId int64, state int) (result int, markedUserIds []int64) {
+	db := GetDb(staticData)
+
+	dare, isFound := db.GetRevealedDare(dareId)
+	if !isFound {
+		return DareNotFound, nil
+	}
+
+	if sessionId, isInSession := db.GetUserSession(userId); !isInSession || sessionId != dare.SessionId {
+		return DareNotFound, nil
+	}
+
+	var userIdsToMark []int64
+	if db.IsUserSessionHost(userId) {
+		for _, player := range dare.Players {
+			userIdsToMark = append(userIdsToMark, player.UserId)
+		}
+	} else if _, isFound := findDarePlayer(&dare, userId); isFound {
+		userIdsToMark = []int64{userId}
+	} else {
+		return DareMarkNotAllowed, nil
+	}
+
+	for _, userIdToMark := range userIdsToMark {
+		if db.SetRevealedDarePlayerState(dareId, userIdToMark, state) {
+			markedUserIds = append(markedUserIds, userIdToMark)
+		}
+	}
+
+	if len(markedUserIds) == 0 {
+		return DareAlreadyMarked, nil
+	}
+
+	if state == database.DareStateSkipped {
+		for _, markedUserId := range markedUserIds {
+			sendPenalty(staticData, dare.SessionId, markedUserId)
+		}
+	}
+	return DareMarked, markedUserIds
+}
+
+// draws a penalty from the pool of the session for the player who skipped a dare
+func sendPenalty(staticData *processing.StaticProccessStructs, sessionId int64, userId int64) {
+	command, isFound := GetPlayablePenaltyCommand(staticData, sessionId, userId)
+	if isFound {
+		SendPenaltyCommand(staticData, sessionId, command, userId)
+	}
+}
+
+func GetMarkDareErrorMessage(result int, trans i18n.TranslateFunc) string {
+	switch result {
+	case DareNotFound:
+		return trans("session_is_too_old")
+	case DareMarkNotAllowed:
+		return trans("cant_mark_dare")
+	case DareAlreadyMarked:
+		return trans("dare_already_marked")
+	}
+	return ""
+}
+
+// the dare text followed by what the named players did with it
+func FormatRevealedDare(dare *database.RevealedDareInfo, trans i18n.TranslateFunc) string {
+	lines := []string{dare.Command}
+	for _, player := range dare.Players {
+		switch player.State {
+		case database.DareStateDone:
+			lines = append(lines, trans("dare_done_by", map[string]interface{}{
+				"Name": player.Name,
+			}))
+		case database.DareStateSkipped:
+			lines = append(lines, trans("dare_skipped_by", map[string]interface{}{
+				"Name": player.Name,
+			}))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func GetSessionScoreMessage(staticData *processing.StaticProccessStructs, sessionId int64, trans i18n.TranslateFunc) string {
+	db := GetDb(staticData)
+
+	scores := db.GetSessionScores(sessionId)
+	// penalty points are shown only if they are used in the session
+	hasPenalties := db.GetSessionSkipPenalty(sessionId) > 0
+	for _, score := range scores {
+		if score.PenaltyPoints > 0 {
+			hasPenalties = true
+		}
+	}
+
+	lines := []string{trans("score_title")}
+
+	for i, score := range scores {
+		templateData := map[string]interface{}{
+			"Place":   i + 1,
+			"Name":    score.Name,
+			"Done":    score.DoneCount,
+			"Skipped": score.SkippedCount,
+			"Penalty": score.PenaltyPoints,
+		}
+		if hasPenalties {
+			lines = append(lines, trans("score_line_with_penalty", templateData))
+		} else {
+			lines = append(lines, trans("score_line", templateData))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -1,70 +1,89 @@
 package staticFunctions
 
 import (
-	"fmt"
 	"github.com/gameraccoon/telegram-bot-skeleton/processing"
 	"github.com/gameraccoon/telegram-the-king-says-bot/database"
 	static "github.com/gameraccoon/telegram-the-king-says-bot/staticData"
+	"log"
 	"math/rand"
 	"sort"
 	"strings"
 )
 
-func sendNumbers(staticData *processing.StaticProccessStructs, userIds []int64) {
-	db := GetDb(staticData)
-
-	maleIdx := 0
-	femaleIdx := 0
-
-	for i, userId := range userIds {
-		gender := db.GetUserGender(userId)
+// each group and each team has its own numbering, so the players can be counted off within them
+func sendNumbers(staticData *processing.StaticProccessStructs, users []database.SessionUserInfo, placeholders *static.PlaceholderInfos) {
+	groups := GetGroups(staticData)
+	groupIndexes := make(map[string]int)
+	teamIndexes := make(map[int]int)
 
-		if gender == 0 {
+	for i, user := range users {
+		if len(user.Groups) == 0 && user.Team == 0 {
 			continue
 		}
 
-		trans := FindTransFunction(userId, staticData)
+		trans := FindTransFunction(user.UserId, staticData)
 
 		message := trans("player_number_msg", map[string]interface{}{
 			"Number": i + 1,
 		})
 
-		if gender&1 != 0 {
-			if len(message) > 0 {
-				message += "\n"
+		for groupIdx := range groups {
+			group := &groups[groupIdx]
+			if !containsString(user.Groups, group.Key) {
+				continue
 			}
-			message += trans("female_number", map[string]interface{}{
-				"Number": femaleIdx + 1,
-			})
-			femaleIdx++
-		}
 
-		if gender&2 != 0 {
+			groupIndexes[group.Key]++
 			if len(message) > 0 {
 				message += "\n"
 			}
-			message += trans("male_number", map[string]interface{}{
-				"Number": maleIdx + 1,
+			message += trans("group_number", map[string]interface{}{
+				"Group":  GetGroupName(group, trans),
+				"Number": groupIndexes[group.Key],
 			})
-			maleIdx++
 		}
 
-		chatId, isFound := db.GetTelegramUserChatId(userId)
-		if isFound {
-			staticData.Chat.SendMessage(chatId, message, 0, true)
-		} else {
-			db.AddWebMessage(userId, message, 10)
+		if user.Team != 0 {
+			teamIndexes[user.Team]++
+			message += "\n" + trans("group_number", map[string]interface{}{
+				"Group":  getTeamName(placeholders, user.Team, trans),
+				"Number": teamIndexes[user.Team],
+			})
 		}
+
+		sendTextToPlayer(staticData, &user, message)
 	}
 }
 
 func GiveRandomNumbersToPlayers(staticData *processing.StaticProccessStructs, sessionId int64) {
 	db := GetDb(staticData)
-	userIds := db.GetUsersInSession(sessionId)
 
-	rand.Shuffle(len(userIds), func(i, j int) { userIds[i], userIds[j] = userIds[j], userIds[i] })
+	users := getActiveUsers(db.GetUsersInSessionInfo(sessionId))
+
+	rand.Shuffle(len(users), func(i, j int) { users[i], users[j] = users[j], users[i] })
 
-	sendNumbers(staticData, userIds)
+	sendNumbers(staticData, users, GetSessionPlaceholders(staticData, sessionId))
+}
+
+// the players who don't sit out
+func getActiveUsers(users []database.SessionUserInfo) (activeUsers []database.SessionUserInfo) {
+	for _, user := range users {
+		if !user.IsPaused {
+			activeUsers = append(activeUsers, user)
+		}
+	}
+	return
+}
+
+// returns the names of the players who sit out joined with commas, empty if there are none
+func GetPausedPlayerNames(staticData *processing.StaticProccessStructs, sessionId int64) string {
+	var names []string
+	for _, user := range GetDb(staticData).GetUsersInSessionInfo(sessionId) {
+		if user.IsPaused {
+			names = append(names, user.Name)
+		}
+	}
+	return strings.Join(names, ", ")
 }
 
 func getPlaceholders(staticData *processing.StaticProccessStructs) *static.PlaceholderInfos {
@@ -77,51 +96,84 @@ func getPlaceholders(staticData *processing.StaticProccessStructs) *static.Place
 	return &config.Placeholders
 }
 
+// numbered placeholders of the same kind name the same player
+type placeholderBinding struct {
+	placeholder *static.PlaceholderInfo
+	index       int
+}
+
+const (
+	singlePlayerMatch = iota
+	otherPlayersMatch
+	allPlayersMatch
+	otherTeamMatch
+)
+
 type placeholderMatch struct {
-	at        int
-	len       int
-	matchType int
-	name      string
+	at  int
+	len int
+	// the key of the group the named player should be in, empty if any player fits
+	group string
+	// the team the named player should be in, zero if any player fits
+	team    int
+	kind    int
+	binding placeholderBinding
+	name    string
+	// the player named by the match, zero if there is no such player
+	userId int64
 }
 
-func appendMatches(matches *[]placeholderMatch, sequence []byte, placeholder *static.PlaceholderInfo, matchType int) {
+func isBound(binding placeholderBinding) bool {
+	return binding.index != 0
+}
+
+func appendMatches(matches *[]placeholderMatch, sequence []byte, placeholder *static.PlaceholderInfo, group string) {
 	resp := placeholder.Matcher.Match(sequence)
 	defer resp.Release()
 
 	for resp.HasNext() {
 		items := resp.NextMatchItem(sequence)
 		for _, itr := range items {
+			index, _ := itr.Value.(int)
 			*matches = append(*matches, placeholderMatch{
-				at:        itr.At - itr.KLen + 1,
-				len:       itr.KLen,
-				matchType: matchType,
+				at:      itr.At - itr.KLen + 1,
+				len:     itr.KLen,
+				group:   group,
+				binding: placeholderBinding{placeholder: placeholder, index: index},
 			})
 		}
 	}
 }
 
-func appendOppositeMatches(matches *[]placeholderMatch, sequence []byte, placeholder *[2]static.PlaceholderInfo) {
-	var oppositeGendersIndexes [2]int
+func appendGroupMatches(matches *[]placeholderMatch, sequence []byte, placeholder *static.PlaceholderInfo, kind int) {
+	firstIdx := len(*matches)
+	appendMatches(matches, sequence, placeholder, "")
+	for i := firstIdx; i < len(*matches); i++ {
+		(*matches)[i].kind = kind
+	}
+}
+
+func appendTeamMatches(matches *[]placeholderMatch, sequence []byte, teams []static.PlaceholderInfo) {
+	for teamIdx := range teams {
+		firstIdx := len(*matches)
+		appendMatches(matches, sequence, &teams[teamIdx], "")
+		for i := firstIdx; i < len(*matches); i++ {
+			(*matches)[i].team = teamIdx + 1
+		}
+	}
+}
+
+func getRandomOppositeGroups(placeholders *static.PlaceholderInfos) [2]string {
 	if rand.Intn(2) == 0 {
-		oppositeGendersIndexes = [2]int{2, 1}
+		return [2]string{placeholders.OppositeGroups[1], placeholders.OppositeGroups[0]}
 	} else {
-		oppositeGendersIndexes = [2]int{1, 2}
+		return placeholders.OppositeGroups
 	}
+}
 
-	for placeholderIdx, gender := range oppositeGendersIndexes {
-		resp := placeholder[placeholderIdx].Matcher.Match(sequence)
-		defer resp.Release()
-
-		for resp.HasNext() {
-			items := resp.NextMatchItem(sequence)
-			for _, itr := range items {
-				*matches = append(*matches, placeholderMatch{
-					at:        itr.At - itr.KLen + 1,
-					len:       itr.KLen,
-					matchType: gender,
-				})
-			}
-		}
+func appendOppositeMatches(matches *[]placeholderMatch, sequence []byte, placeholder *[2]static.PlaceholderInfo, oppositeGroups [2]string) {
+	for placeholderIdx, group := range oppositeGroups {
+		appendMatches(matches, sequence, &placeholder[placeholderIdx], group)
 	}
 }
 
@@ -134,87 +186,230 @@ func removeIntersectedMatches(matches *[]placeholderMatch) {
 	}
 }
 
-func getAndRemoveParticipatingUserName(users *[]database.SessionUserInfo, matchType int) string {
-	for i, user := range *users {
-		if matchType == 0 || (matchType&user.Gender != 0) {
-			*users = append((*users)[:i], (*users)[i+1:]...)
-			return user.Name
+// any player is in the empty group
+func isInGroup(user *database.SessionUserInfo, group string) bool {
+	return len(group) == 0 || containsString(user.Groups, group)
+}
+
+func canBeNamedByMatch(user *database.SessionUserInfo, match *placeholderMatch) bool {
+	return isInGroup(user, match.group) && (match.team == 0 || user.Team == match.team)
+}
+
+// the matches that can't name any player are filled first, so the other matches don't take their players
+func isRestrictedMatch(match *placeholderMatch) bool {
+	return len(match.group) > 0 || match.team != 0
+}
+
+// returns the single player matches with specified groups or teams that still need a player, one for each binding
+func getRestrictedMatchesToFill(matches []placeholderMatch, boundMatches map[placeholderBinding]*placeholderMatch) (restrictedMatches []*placeholderMatch) {
+	isAdded := make(map[placeholderBinding]bool)
+	for i := range matches {
+		match := &matches[i]
+		if match.kind != singlePlayerMatch || !isRestrictedMatch(match) || len(match.name) > 0 {
+			continue
+		}
+
+		if isBound(match.binding) {
+			if _, isFound := boundMatches[match.binding]; isFound || isAdded[match.binding] {
+				continue
+			}
+			isAdded[match.binding] = true
 		}
+
+		restrictedMatches = append(restrictedMatches, match)
 	}
-	return "[no match]"
+	return
 }
 
-func findMatches(staticData *processing.StaticProccessStructs, sequence []byte) []placeholderMatch {
-	placeholders := getPlaceholders(staticData)
-	matches := make([]placeholderMatch, 0)
+// finds a player for each match so that no player is named twice, the result has the indexes of the players
+// or -1 for the matches that can't get a player, a player who fits several matches is moved to another
+// of them if that frees the only player who fits some other match
+// the players are tried in their order, so the selection policy still decides who is named first
+func assignPlayersToMatches(matches []*placeholderMatch, users []database.SessionUserInfo) (assignedUsers []int, isComplete bool) {
+	userMatches := make([]int, len(users))
+	for i := range userMatches {
+		userMatches[i] = -1
+	}
 
-	appendMatches(&matches, sequence, &placeholders.Common, 0)
-	appendMatches(&matches, sequence, &placeholders.Female, 1)
-	appendMatches(&matches, sequence, &placeholders.Male, 2)
-	appendOppositeMatches(&matches, sequence, &placeholders.Opposite)
+	var tryAssign func(matchIdx int, visitedUsers []bool) bool
+	tryAssign = func(matchIdx int, visitedUsers []bool) bool {
+		for userIdx := range users {
+			if visitedUsers[userIdx] || !canBeNamedByMatch(&users[userIdx], matches[matchIdx]) {
+				continue
+			}
+			visitedUsers[userIdx] = true
+			if userMatches[userIdx] == -1 || tryAssign(userMatches[userIdx], visitedUsers) {
+				userMatches[userIdx] = matchIdx
+				return true
+			}
+		}
+		return false
+	}
 
-	sort.Slice(matches, func(i, j int) bool {
-		return matches[i].at > matches[j].at
-	})
+	isComplete = true
+	for matchIdx := range matches {
+		if !tryAssign(matchIdx, make([]bool, len(users))) {
+			isComplete = false
+		}
+	}
 
-	removeIntersectedMatches(&matches)
-	return matches
+	assignedUsers = make([]int, len(matches))
+	for i := range assignedUsers {
+		assignedUsers[i] = -1
+	}
+	for userIdx, matchIdx := range userMatches {
+		if matchIdx != -1 {
+			assignedUsers[matchIdx] = userIdx
+		}
+	}
+	return
+}
+
+// the players for the matches with specified groups or teams are picked all together,
+// so a player who is in several groups doesn't take the only player who fits another match
+func fillRestrictedMatches(matches []placeholderMatch, users *[]database.SessionUserInfo, boundMatches map[placeholderBinding]*placeholderMatch) {
+	restrictedMatches := getRestrictedMatchesToFill(matches, boundMatches)
+	assignedUsers, _ := assignPlayersToMatches(restrictedMatches, *users)
+
+	isUserAssigned := make([]bool, len(*users))
+	for i, match := range restrictedMatches {
+		if userIdx := assignedUsers[i]; userIdx != -1 {
+			match.name = (*users)[userIdx].Name
+			match.userId = (*users)[userIdx].UserId
+			isUserAssigned[userIdx] = true
+		} else {
+			match.name = "[no match]"
+		}
+
+		if isBound(match.binding) {
+			boundMatches[match.binding] = match
+		}
+	}
+
+	remainingUsers := make([]database.SessionUserInfo, 0, len(*users))
+	for i, user := range *users {
+		if !isUserAssigned[i] {
+			remainingUsers = append(remainingUsers, user)
+		}
+	}
+	*users = remainingUsers
 }
 
-func getUserWeight(user *database.SessionUserInfo) int {
-	weightPower := user.CurrentSessionIdleCount
-	if weightPower > 31 {
-		weightPower = 31
+func getAndRemoveParticipatingUser(users *[]database.SessionUserInfo, match *placeholderMatch) (user database.SessionUserInfo, isFound bool) {
+	for i, user := range *users {
+		if canBeNamedByMatch(&user, match) {
+			*users = append((*users)[:i], (*users)[i+1:]...)
+			return user, true
+		}
+	}
+	return
+}
+
+// the matches are sorted from the end of the text, so the first placeholder is the last match
+func getFirstSinglePlayerMatchIdx(matches []placeholderMatch) (idx int, isFound bool) {
+	for i := len(matches) - 1; i >= 0; i-- {
+		if matches[i].kind == singlePlayerMatch {
+			return i, true
+		}
 	}
+	return
+}
 
-	weight := 1 << weightPower
+// finds the matches of a penalty with the order of the opposite groups that lets its first placeholder name the penalized player
+func findPenaltyMatches(placeholders *static.PlaceholderInfos, sequence []byte, penalizedUser *database.SessionUserInfo) (matches []placeholderMatch, pinnedMatchIdx int, isFound bool) {
+	oppositeGroups := getRandomOppositeGroups(placeholders)
+	for _, groups := range [][2]string{oppositeGroups, {oppositeGroups[1], oppositeGroups[0]}} {
+		matches = findMatchesWithOppositeGroups(placeholders, sequence, groups)
+		pinnedMatchIdx, isFound = getFirstSinglePlayerMatchIdx(matches)
+		if isFound && canBeNamedByMatch(penalizedUser, &matches[pinnedMatchIdx]) {
+			return
+		}
+	}
+	return nil, 0, false
+}
 
-	if weight <= 0 {
-		panic("User weight can never be zero or lower")
+// the penalized player should be able to take the penalty themselves, otherwise it would name someone innocent
+func canTakePenalty(placeholders *static.PlaceholderInfos, contentTags *static.ContentTagInfos, command string, penalizedUser *database.SessionUserInfo) bool {
+	if penalizedUser.IsPaused || !isAcceptingTags(penalizedUser, findCommandTags(contentTags, command)) {
+		return false
 	}
-	return weight
+
+	_, _, isFound := findPenaltyMatches(placeholders, []byte(command), penalizedUser)
+	return isFound
 }
 
-func deleteUnordered(s *[]database.SessionUserInfo, i int) {
-	(*s)[i] = (*s)[len(*s)-1]
-	(*s) = (*s)[:len(*s)-1]
+func getAndRemoveParticipatingUserById(users *[]database.SessionUserInfo, userId int64, match *placeholderMatch) (user database.SessionUserInfo, isFound bool) {
+	for i, user := range *users {
+		if user.UserId == userId && canBeNamedByMatch(&user, match) {
+			*users = append((*users)[:i], (*users)[i+1:]...)
+			return user, true
+		}
+	}
+	return
 }
 
-func weightedShuffle(users []database.SessionUserInfo) []database.SessionUserInfo {
-	if len(users) <= 1 {
-		return users
+func joinUserNames(users []database.SessionUserInfo) string {
+	if len(users) == 0 {
+		return "[nobody]"
 	}
 
-	usersToDrawFrom := make([]database.SessionUserInfo, len(users))
-	copy(usersToDrawFrom, users)
+	names := make([]string, 0, len(users))
+	for _, user := range users {
+		names = append(names, user.Name)
+	}
+	return strings.Join(names, ", ")
+}
 
-	result := []database.SessionUserInfo{}
+func findMatches(placeholders *static.PlaceholderInfos, sequence []byte) []placeholderMatch {
+	return findMatchesWithOppositeGroups(placeholders, sequence, getRandomOppositeGroups(placeholders))
+}
 
-	sum := 0
-	for _, user := range usersToDrawFrom {
-		sum += getUserWeight(&user)
+func findMatchesWithOppositeGroups(placeholders *static.PlaceholderInfos, sequence []byte, oppositeGroups [2]string) []placeholderMatch {
+	matches := make([]placeholderMatch, 0)
+
+	appendMatches(&matches, sequence, &placeholders.Common, "")
+	for group, placeholder := range placeholders.Groups {
+		appendMatches(&matches, sequence, placeholder, group)
 	}
+	appendOppositeMatches(&matches, sequence, &placeholders.Opposite, oppositeGroups)
+	appendTeamMatches(&matches, sequence, placeholders.Teams)
+	appendGroupMatches(&matches, sequence, &placeholders.Others, otherPlayersMatch)
+	appendGroupMatches(&matches, sequence, &placeholders.Everyone, allPlayersMatch)
+	appendGroupMatches(&matches, sequence, &placeholders.OtherTeam, otherTeamMatch)
 
-	for len(usersToDrawFrom) > 1 {
-		weight := rand.Intn(sum)
-		for i, user := range usersToDrawFrom {
-			userWeight := getUserWeight(&user)
-			if weight < userWeight {
-				result = append(result, user)
-				deleteUnordered(&usersToDrawFrom, i)
-				sum -= userWeight
-				break
-			}
-			weight -= userWeight
+	// when several placeholders start at the same place, the longest one should be used
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].at == matches[j].at {
+			return matches[i].len > matches[j].len
 		}
+		return matches[i].at > matches[j].at
+	})
+
+	removeIntersectedMatches(&matches)
+	return matches
+}
+
+const maxCommandLength = 1000
+
+// checks the length and the random values of the dare, dares without placeholders like "everyone drinks" are fine
+func IsCommandValid(command string) bool {
+	command = strings.TrimSpace(command)
+	if len(command) == 0 || len(command) > maxCommandLength {
+		return false
 	}
 
-	if sum != getUserWeight(&usersToDrawFrom[0]) {
-		panic(fmt.Sprintf("The final list doesn't contain some of the records, missing weight %d", sum-getUserWeight(&usersToDrawFrom[0])))
+	_, isFound := FindInvalidRandomValue(command)
+	return !isFound
+}
+
+// the penalty should have a placeholder for one player to name the player who skipped a dare
+func IsPenaltyValid(placeholders *static.PlaceholderInfos, command string) bool {
+	if !IsCommandValid(command) {
+		return false
 	}
-	result = append(result, usersToDrawFrom[0])
 
-	return result
+	_, isFound := getFirstSinglePlayerMatchIdx(findMatches(placeholders, []byte(command)))
+	return isFound
 }
 
 func contains(slice []int64, val int64) bool {
@@ -226,9 +421,23 @@ func contains(slice []int64, val int64) bool {
 	return false
 }
 
-func SendAdvancedCommand(staticData *processing.StaticProccessStructs, sessionId int64, command string) {
+// returns the players named in the dare in the order they appear in the text
+func SendAdvancedCommand(staticData *processing.StaticProccessStructs, sessionId int64, command string) (namedUserIds []int64) {
+	return sendAdvancedCommand(staticData, sessionId, command, 0)
+}
+
+// the penalized player is named in the first placeholder of the penalty, nothing is sent if they can't be named in it
+// penalties don't pass the turn and don't change the chances of the players to be drawn
+func SendPenaltyCommand(staticData *processing.StaticProccessStructs, sessionId int64, command string, penalizedUserId int64) (namedUserIds []int64) {
+	return sendAdvancedCommand(staticData, sessionId, command, penalizedUserId)
+}
+
+func sendAdvancedCommand(staticData *processing.StaticProccessStructs, sessionId int64, command string, pinnedUserId int64) (namedUserIds []int64) {
 	db := GetDb(staticData)
+	isPenalty := pinnedUserId != 0
 	users := db.GetUsersInSessionInfo(sessionId)
+	// the players who sit out still receive the dare but are never named in it
+	activeUsers := getActiveUsers(users)
 
 	{
 		commandLength := 0
@@ -241,22 +450,105 @@ func SendAdvancedCommand(staticData *processing.StaticProccessStructs, sessionId
 		}
 	}
 
+	// the players who declined any tag of the dare are not named in it
+	eligibleUsers := getUsersAcceptingTags(activeUsers, findCommandTags(getContentTags(staticData), command))
+
+	command, _ = expandRandomValues(command, len(activeUsers))
+
 	sequence := []byte(command)
-	matches := findMatches(staticData, sequence)
+	placeholders := GetSessionPlaceholders(staticData, sessionId)
+
+	var matches []placeholderMatch
+	pinnedMatchIdx := 0
+	if isPenalty {
+		pinnedUser, isFound := findUserInfo(eligibleUsers, pinnedUserId)
+		if isFound {
+			matches, pinnedMatchIdx, isFound = findPenaltyMatches(placeholders, sequence, &pinnedUser)
+		}
+		if !isFound {
+			log.Printf("Can't name player %d in the penalty, it is not sent", pinnedUserId)
+			return
+		}
+	} else {
+		matches = findMatches(placeholders, sequence)
+	}
+
+	participatingUsers := getSelectionPolicy(db.GetSessionSelectionPolicy(sessionId))(eligibleUsers)
+
+	boundMatches := make(map[placeholderBinding]*placeholderMatch)
+	fillName := func(match *placeholderMatch) {
+		if isBound(match.binding) {
+			if boundMatch, isFound := boundMatches[match.binding]; isFound {
+				match.name = boundMatch.name
+				match.userId = boundMatch.userId
+				return
+			}
+		}
+
+		if user, isFound := getAndRemoveParticipatingUser(&participatingUsers, match); isFound {
+			match.name = user.Name
+			match.userId = user.UserId
+		} else {
+			match.name = "[no match]"
+		}
 
-	participatingUsers := weightedShuffle(users)
+		if isBound(match.binding) {
+			boundMatches[match.binding] = match
+		}
+	}
 
-	// fill the names for the matches with specified genders
+	if isPenalty {
+		pinnedMatch := &matches[pinnedMatchIdx]
+		if user, isFound := getAndRemoveParticipatingUserById(&participatingUsers, pinnedUserId, pinnedMatch); isFound {
+			pinnedMatch.name = user.Name
+			pinnedMatch.userId = user.UserId
+			if isBound(pinnedMatch.binding) {
+				boundMatches[pinnedMatch.binding] = pinnedMatch
+			}
+		}
+	}
+
+	// the pairs are filled first so the players in them follow the pairing rules they agreed to
+	if constraints := getAgreedPairConstraints(db.GetSessionPairConstraints(sessionId)); !constraints.isEmpty() {
+		for _, pair := range findOppositePairs(matches, &placeholders.Opposite) {
+			fillOppositePair([2]*placeholderMatch{&matches[pair[0]], &matches[pair[1]]}, &participatingUsers, boundMatches, &constraints)
+		}
+	}
+
+	// fill the names for the matches with specified groups or teams
+	fillRestrictedMatches(matches, &participatingUsers, boundMatches)
+	// the other placeholders with the same bindings get the same players
 	for i, match := range matches {
-		if match.matchType != 0 {
-			matches[i].name = getAndRemoveParticipatingUserName(&participatingUsers, match.matchType)
+		if match.kind == singlePlayerMatch && isRestrictedMatch(&match) && len(match.name) == 0 {
+			fillName(&matches[i])
 		}
 	}
 
 	// fill the names for all the others
 	for i, match := range matches {
-		if len(match.name) == 0 {
-			matches[i].name = getAndRemoveParticipatingUserName(&participatingUsers, match.matchType)
+		if match.kind == singlePlayerMatch && len(match.name) == 0 {
+			fillName(&matches[i])
+		}
+	}
+
+	// the other team is picked once, so all its placeholders name the same players
+	var otherTeamUsers []database.SessionUserInfo
+	isOtherTeamPicked := false
+
+	// the groups are filled with the players who are left after everyone was drawn
+	for i, match := range matches {
+		switch match.kind {
+		case otherPlayersMatch:
+			matches[i].name = joinUserNames(participatingUsers)
+		case allPlayersMatch:
+			matches[i].name = joinUserNames(eligibleUsers)
+		case otherTeamMatch:
+			if !isOtherTeamPicked {
+				otherTeam := pickOtherTeam(db.GetSessionTeamsCount(sessionId), getFirstNamedTeam(matches, eligibleUsers))
+				otherTeamUsers = getTeamUsers(eligibleUsers, otherTeam)
+				isOtherTeamPicked = true
+			}
+			matches[i].name = joinUserNames(otherTeamUsers)
 		}
 	}
 
@@ -267,28 +559,55 @@ func SendAdvancedCommand(staticData *processing.StaticProccessStructs, sessionId
 
 	message := string(sequence)
 
+	// the matches are sorted from the end of the text
+	for i := len(matches) - 1; i >= 0; i-- {
+		if matches[i].userId != 0 && !contains(namedUserIds, matches[i].userId) {
+			namedUserIds = append(namedUserIds, matches[i].userId)
+		}
+	}
+
+	if !isPenalty {
+		passKingTurn(staticData, sessionId, namedUserIds)
+	}
+
+	// the named players will mark whether they did the dare
+	var dare database.RevealedDareInfo
+	isTracked := len(namedUserIds) > 0
+	if isTracked {
+		dareId := db.AddRevealedDare(sessionId, message, namedUserIds)
+		dare, isTracked = db.GetRevealedDare(dareId)
+	}
+
 	// transmit the message to all players in the session
 	ResendSessionDialogs(sessionId, staticData)
 	for _, user := range users {
 		if user.IsWebUser {
 			db.AddWebMessage(user.UserId, message, 10)
+			notifyWebPlayer(user.UserId)
+		} else if isTracked && CanMarkRevealedDare(staticData, &dare, user.UserId) {
+			trans := FindTransFunction(user.UserId, staticData)
+			staticData.Chat.SendDialog(user.ChatId, staticData.MakeDialogFn("rd", user.UserId, trans, staticData, dare.Id), 0)
 		} else {
 			staticData.Chat.SendMessage(user.ChatId, message, 0, true)
 		}
 	}
 
 	// increase idle counters for players who didn't participate and reset for the ones who participated
-	{
+	// only the drawn players are counted as participated, so group dares don't affect the draw weights
+	// the counters of the players who sit out or declined the dare stay as they are
+	if !isPenalty && len(participatingUsers) < len(eligibleUsers) {
 		var nonParticipatedIds []int64
 		for _, user := range participatingUsers {
 			nonParticipatedIds = append(nonParticipatedIds, user.UserId)
 		}
 		var participatedIds []int64
-		for _, user := range users {
+		for _, user := range eligibleUsers {
 			if !contains(nonParticipatedIds, user.UserId) {
 				participatedIds = append(participatedIds, user.UserId)
 			}
 		}
 		db.UpdateUsersIdleCount(nonParticipatedIds, 1, participatedIds)
 	}
+
+	return
 }
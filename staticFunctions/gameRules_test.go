@@ -0,0 +1,192 @@
+package staticFunctions
+
+import (
+	"github.com/gameraccoon/telegram-bot-skeleton/processing"
+	"github.com/gameraccoon/telegram-the-king-says-bot/database"
+	static "github.com/gameraccoon/telegram-the-king-says-bot/staticData"
+	"github.com/stretchr/testify/require"
+	"os"
+	"strings"
+	"testing"
+)
+
+func makeTestPlaceholders(t *testing.T) *static.PlaceholderInfos {
+	placeholders := &static.PlaceholderInfos{
+		Common: static.PlaceholderInfo{Values: []string{"🎲"}},
+		Groups: map[string]*static.PlaceholderInfo{
+			"female": {Values: []string{"🚺"}},
+			"male":   {Values: []string{"🚹"}},
+		},
+		Opposite:       [2]static.PlaceholderInfo{{Values: []string{"❤️"}}, {Values: []string{"💙"}}},
+		OppositeGroups: [2]string{"female", "male"},
+		OtherTeam:      static.PlaceholderInfo{Values: []string{"🆚"}},
+		Others:         static.PlaceholderInfo{Values: []string{"👥"}},
+		Everyone:       static.PlaceholderInfo{Values: []string{"🌍"}},
+		MaxIndex:       3,
+	}
+	require.Nil(t, placeholders.Compile(nil, nil))
+	return placeholders
+}
+
+func makeTestContentTags(t *testing.T) *static.ContentTagInfos {
+	contentTags := &static.ContentTagInfos{
+		Tags: []static.ContentTagInfo{{Name: "kiss", Keywords: []string{"kiss"}}},
+	}
+	require.Nil(t, contentTags.Compile())
+	return contentTags
+}
+
+func TestCanTakePenalty(t *testing.T) {
+	placeholders := makeTestPlaceholders(t)
+	contentTags := makeTestContentTags(t)
+
+	girl := database.SessionUserInfo{UserId: 1, Groups: []string{"female"}}
+	pausedGirl := database.SessionUserInfo{UserId: 2, Groups: []string{"female"}, IsPaused: true}
+	shyGirl := database.SessionUserInfo{UserId: 3, Groups: []string{"female"}, DeclinedTags: []string{"kiss"}}
+
+	testCases := []struct {
+		name    string
+		command string
+		user    *database.SessionUserInfo
+		canTake bool
+	}{
+		{"any player", "🎲 drinks", &girl, true},
+		{"own group", "🚺 drinks", &girl, true},
+		{"other group", "🚹 drinks", &girl, false},
+		{"only the first placeholder is pinned", "🚹 gives a drink to 🚺", &girl, false},
+		// the opposite groups are picked so the first placeholder fits the penalized player
+		{"opposite placeholder", "❤️ kisses 💙", &girl, true},
+		{"other opposite placeholder", "💙 hugs ❤️", &girl, true},
+		{"no single player placeholder", "everyone drinks", &girl, false},
+		{"only group placeholders", "🌍 drink", &girl, false},
+		{"paused player", "🎲 drinks", &pausedGirl, false},
+		{"declined tag", "🎲 drinks #kiss", &shyGirl, false},
+		{"declined tag keyword", "🎲 gives a kiss to 🚹", &shyGirl, false},
+		{"not declined tag", "🎲 drinks", &shyGirl, true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			for i := 0; i < 20; i++ {
+				require.Equal(t, testCase.canTake, canTakePenalty(placeholders, contentTags, testCase.command, testCase.user))
+			}
+		})
+	}
+}
+
+func TestFindPenaltyMatchesPinsFirstPlaceholder(t *testing.T) {
+	assert := require.New(t)
+	placeholders := makeTestPlaceholders(t)
+
+	boy := database.SessionUserInfo{UserId: 1, Groups: []string{"male"}}
+	sequence := []byte("❤️ kisses 💙")
+
+	// the order of the opposite groups is random, the first placeholder should always fit
+	for i := 0; i < 20; i++ {
+		matches, pinnedMatchIdx, isFound := findPenaltyMatches(placeholders, sequence, &boy)
+		assert.True(isFound)
+		assert.Equal(0, matches[pinnedMatchIdx].at)
+		assert.Equal("male", matches[pinnedMatchIdx].group)
+	}
+}
+
+func TestGetPlayablePenaltyCommand(t *testing.T) {
+	assert := require.New(t)
+
+	const testDbPath = "./testPenaltiesDb.db"
+	_ = os.Remove(testDbPath)
+	defer os.Remove(testDbPath)
+
+	db, err := database.ConnectDb(testDbPath)
+	assert.Nil(err)
+	defer db.Disconnect()
+
+	staticData := &processing.StaticProccessStructs{
+		Db: db,
+		Config: static.StaticConfiguration{
+			Placeholders: *makeTestPlaceholders(t),
+			ContentTags:  *makeTestContentTags(t),
+		},
+	}
+
+	sessionId, _ := db.CreateWebSession(1, "girl", []string{"female"})
+	db.AddWebUser(sessionId, 2, "boy", []string{"male"})
+	girlUserId, _ := db.GetWebUserId(1)
+
+	db.AddSessionPenaltyCommand(sessionId, "🚹 drinks")
+	db.AddSessionPenaltyCommand(sessionId, "🚺 kisses 🚹")
+	db.AddSessionPenaltyCommand(sessionId, "everyone drinks")
+
+	for i := 0; i < 20; i++ {
+		command, isFound := GetPlayablePenaltyCommand(staticData, sessionId, girlUserId)
+		assert.True(isFound)
+		assert.Equal("🚺 kisses 🚹", command)
+	}
+
+	db.SetUserDeclinedTags(girlUserId, []string{"kiss"})
+	_, isFound := GetPlayablePenaltyCommand(staticData, sessionId, girlUserId)
+	assert.False(isFound)
+
+	db.SetUserDeclinedTags(girlUserId, []string{})
+	db.SetUserPaused(girlUserId, true)
+	_, isFound = GetPlayablePenaltyCommand(staticData, sessionId, girlUserId)
+	assert.False(isFound)
+}
+
+func TestCommandValidation(t *testing.T) {
+	assert := require.New(t)
+	placeholders := makeTestPlaceholders(t)
+
+	assert.True(IsCommandValid("everyone drinks"))
+	assert.True(IsCommandValid("🎲 drinks {1-3} sips"))
+	assert.False(IsCommandValid("  "))
+	assert.False(IsCommandValid("everyone drinks {3-1} sips"))
+	assert.False(IsCommandValid(strings.Repeat("a", maxCommandLength+1)))
+
+	assert.True(IsPenaltyValid(placeholders, "🎲 drinks"))
+	assert.True(IsPenaltyValid(placeholders, "❤️ kisses 💙"))
+	assert.False(IsPenaltyValid(placeholders, "everyone drinks"))
+	assert.False(IsPenaltyValid(placeholders, "🌍 drink"))
+	assert.False(IsPenaltyValid(placeholders, "🎲 drinks {3-1} sips"))
+
+	commands, skippedCount := filterValidCommands([]database.CategorizedCommand{
+		{Command: "everyone drinks", Category: database.DareCategory},
+		{Command: " 🎲 sings ", Category: database.TruthCategory},
+		{Command: "", Category: database.DareCategory},
+		{Command: "drink {x|}", Category: database.DareCategory},
+	})
+	assert.Equal([]database.CategorizedCommand{
+		{Command: "everyone drinks", Category: database.DareCategory},
+		{Command: "🎲 sings", Category: database.TruthCategory},
+	}, commands)
+	assert.Equal(2, skippedCount)
+}
+
+func TestDarePackFileKeepsCategories(t *testing.T) {
+	assert := require.New(t)
+
+	commands := []database.CategorizedCommand{
+		{Command: "🎲 sings", Category: database.DareCategory},
+		{Command: "🎲 tells a secret", Category: database.TruthCategory},
+	}
+
+	pack, err := parseDarePackFile("pack.json", makeDarePackFile("pack", "en-us", commands))
+	assert.Nil(err)
+	assert.Equal("pack", pack.Name)
+	assert.Equal("en-us", pack.Language)
+	assert.Equal(commands, pack.getCommands())
+
+	// lists of dares and files exported before the truths were stored have only dares
+	pack, err = parseDarePackFile("pack.json", []byte(`{"commands": ["🎲 sings"]}`))
+	assert.Nil(err)
+	assert.Equal(commands[:1], pack.getCommands())
+
+	pack, err = parseDarePackFile("pack.json", []byte(`["🎲 sings"]`))
+	assert.Nil(err)
+	assert.Equal(commands[:1], pack.getCommands())
+
+	pack, err = parseDarePackFile("pack.csv", []byte("command,language,category\n🎲 sings,en-us,dare\n🎲 tells a secret,en-us,Truth\n"))
+	assert.Nil(err)
+	assert.Equal("en-us", pack.Language)
+	assert.Equal(commands, pack.getCommands())
+}
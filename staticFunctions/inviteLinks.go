@@ -0,0 +1,55 @@
+package staticFunctions
+
+import (
+	"github.com/gameraccoon/telegram-bot-skeleton/processing"
+	static "github.com/gameraccoon/telegram-the-king-says-bot/staticData"
+	"github.com/go-telegram-bot-api/telegram-bot-api"
+	"github.com/skip2/go-qrcode"
+	"log"
+)
+
+// the width and the height of the QR code images in pixels
+const inviteQrCodeSize = 256
+
+func GetInviteLink(staticData *processing.StaticProccessStructs, sessionToken string) string {
+	config, configCastSuccess := staticData.Config.(static.StaticConfiguration)
+
+	if !configCastSuccess {
+		config = static.StaticConfiguration{}
+	}
+
+	return config.ShareWebAddress + "/invite/" + sessionToken
+}
+
+// the QR code is rendered locally, so the session tokens don't leave the server
+func MakeInviteQrCode(staticData *processing.StaticProccessStructs, sessionToken string) (png []byte, err error) {
+	return qrcode.Encode(GetInviteLink(staticData, sessionToken), qrcode.Medium, inviteQrCodeSize)
+}
+
+func SendPhoto(staticData *processing.StaticProccessStructs, chatId int64, fileName string, content []byte, caption string) {
+	chat := getTelegramChat(staticData)
+
+	photo := tgbotapi.NewPhotoUpload(chatId, tgbotapi.FileBytes{
+		Name:  fileName,
+		Bytes: content,
+	})
+	photo.Caption = caption
+
+	chat.LockMutex()
+	_, err := chat.GetBot().Send(photo)
+	chat.UnlockMutex()
+
+	if err != nil {
+		log.Printf("Can't send photo to chat %d: %s", chatId, err.Error())
+	}
+}
+
+func SendInviteQrCode(data *processing.ProcessData, sessionToken string, caption string) {
+	png, err := MakeInviteQrCode(data.Static, sessionToken)
+	if err != nil {
+		log.Printf("Can't make QR code for session token %s: %s", sessionToken, err.Error())
+		return
+	}
+
+	SendPhoto(data.Static, data.ChatId, "invite.png", png, caption)
+}
@@ -0,0 +1,116 @@
+package staticFunctions
+
+import (
+	"github.com/gameraccoon/telegram-bot-skeleton/processing"
+	"github.com/gameraccoon/telegram-the-king-says-bot/database"
+	"github.com/nicksnyder/go-i18n/i18n"
+	"math/rand"
+	"sort"
+)
+
+// players in the order they joined the session, the players who sit out don't get the turn
+func getSessionUsersInOrder(db *database.GameDb, sessionId int64) []int64 {
+	users := db.GetUsersInSessionInfo(sessionId)
+	if activeUsers := getActiveUsers(users); len(activeUsers) > 0 {
+		users = activeUsers
+	}
+
+	userIds := make([]int64, 0, len(users))
+	for _, user := range users {
+		userIds = append(userIds, user.UserId)
+	}
+	sort.Slice(userIds, func(i, j int) bool {
+		return userIds[i] < userIds[j]
+	})
+	return userIds
+}
+
+func getNextUserInOrder(userIds []int64, currentUserId int64) int64 {
+	for i, userId := range userIds {
+		if userId > currentUserId {
+			return userIds[i]
+		}
+	}
+	return userIds[0]
+}
+
+// returns the player whose turn it is to reveal, if the session is played in turns
+// if the king has left the session the turn goes to the next player
+func GetSessionKing(staticData *processing.StaticProccessStructs, sessionId int64) (kingUserId int64, isFound bool) {
+	db := GetDb(staticData)
+
+	if db.GetSessionKingMode(sessionId) == database.KingModeOff {
+		return
+	}
+
+	kingUserId, isInSession := db.GetSessionKing(sessionId)
+	if isInSession {
+		return kingUserId, true
+	}
+
+	userIds := getSessionUsersInOrder(db, sessionId)
+	if len(userIds) == 0 {
+		return
+	}
+
+	kingUserId = getNextUserInOrder(userIds, kingUserId)
+	db.SetSessionKing(sessionId, kingUserId)
+	return kingUserId, true
+}
+
+func IsUserTurnToReveal(staticData *processing.StaticProccessStructs, sessionId int64, userId int64) bool {
+	kingUserId, isFound := GetSessionKing(staticData, sessionId)
+	return !isFound || kingUserId == userId
+}
+
+func GetNotYourTurnMessage(staticData *processing.StaticProccessStructs, sessionId int64, trans i18n.TranslateFunc) string {
+	kingUserId, _ := GetSessionKing(staticData, sessionId)
+	return trans("not_your_turn", map[string]interface{}{
+		"King": GetDb(staticData).GetUserName(kingUserId),
+	})
+}
+
+func passKingTurn(staticData *processing.StaticProccessStructs, sessionId int64, namedUserIds []int64) {
+	db := GetDb(staticData)
+
+	kingUserId, isFound := GetSessionKing(staticData, sessionId)
+	if !isFound {
+		return
+	}
+
+	userIds := getSessionUsersInOrder(db, sessionId)
+
+	switch db.GetSessionKingMode(sessionId) {
+	case database.KingModeInOrder:
+		kingUserId = getNextUserInOrder(userIds, kingUserId)
+	case database.KingModeRandom:
+		if len(userIds) > 1 {
+			// the turn never stays with the same player
+			nextKingIdx := rand.Intn(len(userIds) - 1)
+			if userIds[nextKingIdx] == kingUserId {
+				nextKingIdx = len(userIds) - 1
+			}
+			kingUserId = userIds[nextKingIdx]
+		}
+	case database.KingModePickedByDare:
+		if len(namedUserIds) > 0 {
+			kingUserId = namedUserIds[0]
+		} else {
+			kingUserId = getNextUserInOrder(userIds, kingUserId)
+		}
+	}
+
+	db.SetSessionKing(sessionId, kingUserId)
+}
+
+// the player who starts playing in turns becomes the first king
+func SetSessionKingMode(staticData *processing.StaticProccessStructs, sessionId int64, kingMode int, firstKingUserId int64) {
+	db := GetDb(staticData)
+
+	if db.GetSessionKingMode(sessionId) == database.KingModeOff {
+		db.SetSessionKing(sessionId, firstKingUserId)
+	}
+	db.SetSessionKingMode(sessionId, kingMode)
+
+	UpdateSessionDialogs(sessionId, staticData)
+}
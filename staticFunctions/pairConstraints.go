@@ -0,0 +1,260 @@
+package staticFunctions
+
+import (
+	"github.com/gameraccoon/telegram-bot-skeleton/processing"
+	"github.com/gameraccoon/telegram-the-king-says-bot/database"
+	static "github.com/gameraccoon/telegram-the-king-says-bot/staticData"
+	"github.com/nicksnyder/go-i18n/i18n"
+	"strings"
+)
+
+// the pairing rules that both players of a pair chose
+type agreedPairConstraints struct {
+	never map[[2]int64]bool
+	// the only partners the player can be paired with
+	only map[int64][]int64
+}
+
+func makePairKey(userId1 int64, userId2 int64) [2]int64 {
+	if userId1 > userId2 {
+		return [2]int64{userId2, userId1}
+	}
+	return [2]int64{userId1, userId2}
+}
+
+func getAgreedPairConstraints(constraints []database.PairConstraintInfo) (agreed agreedPairConstraints) {
+	agreed.never = make(map[[2]int64]bool)
+	agreed.only = make(map[int64][]int64)
+
+	for _, constraint := range constraints {
+		// each agreed rule is counted once, from the side of the player with the smaller id
+		if constraint.UserId > constraint.PartnerUserId || GetPairConstraint(constraints, constraint.PartnerUserId, constraint.UserId) != constraint.Kind {
+			continue
+		}
+
+		switch constraint.Kind {
+		case database.PairConstraintNever:
+			agreed.never[makePairKey(constraint.UserId, constraint.PartnerUserId)] = true
+		case database.PairConstraintOnly:
+			agreed.only[constraint.UserId] = append(agreed.only[constraint.UserId], constraint.PartnerUserId)
+			agreed.only[constraint.PartnerUserId] = append(agreed.only[constraint.PartnerUserId], constraint.UserId)
+		}
+	}
+	return
+}
+
+func (agreed *agreedPairConstraints) isEmpty() bool {
+	return len(agreed.never) == 0 && len(agreed.only) == 0
+}
+
+func (agreed *agreedPairConstraints) isAllowedPartner(userId int64, partnerUserId int64) bool {
+	partners := agreed.only[userId]
+	return len(partners) == 0 || contains(partners, partnerUserId)
+}
+
+// zero user id means that the slot is not filled with a player and allows any partner
+func (agreed *agreedPairConstraints) canPair(userId1 int64, userId2 int64) bool {
+	if userId1 == 0 || userId2 == 0 {
+		return true
+	}
+	return !agreed.never[makePairKey(userId1, userId2)] && agreed.isAllowedPartner(userId1, userId2) && agreed.isAllowedPartner(userId2, userId1)
+}
+
+// returns the rule that the player chose for the partner
+func GetPairConstraint(constraints []database.PairConstraintInfo, userId int64, partnerUserId int64) int {
+	for _, constraint := range constraints {
+		if constraint.UserId == userId && constraint.PartnerUserId == partnerUserId {
+			return constraint.Kind
+		}
+	}
+	return database.PairConstraintNone
+}
+
+// returns the indexes of the opposite matches that name a pair of players
+// the placeholders with the same index make a pair, the ones without index are paired in the order they appear
+func findOppositePairs(matches []placeholderMatch, opposite *[2]static.PlaceholderInfo) (pairs [][2]int) {
+	var indexOrder []int
+	indexedPairs := make(map[int]*[2]int)
+	var notIndexed [2][]int
+
+	// the matches are sorted from the end of the text
+	for i := len(matches) - 1; i >= 0; i-- {
+		for side := range opposite {
+			if matches[i].binding.placeholder != &opposite[side] {
+				continue
+			}
+
+			index := matches[i].binding.index
+			if index == 0 {
+				notIndexed[side] = append(notIndexed[side], i)
+				continue
+			}
+
+			pair, isFound := indexedPairs[index]
+			if !isFound {
+				pair = &[2]int{-1, -1}
+				indexedPairs[index] = pair
+				indexOrder = append(indexOrder, index)
+			}
+			// the other matches with the same index name the same player
+			if pair[side] == -1 {
+				pair[side] = i
+			}
+		}
+	}
+
+	for _, index := range indexOrder {
+		if pair := indexedPairs[index]; pair[0] != -1 && pair[1] != -1 {
+			pairs = append(pairs, *pair)
+		}
+	}
+
+	for i := 0; i < len(notIndexed[0]) && i < len(notIndexed[1]); i++ {
+		pairs = append(pairs, [2]int{notIndexed[0][i], notIndexed[1][i]})
+	}
+	return
+}
+
+// returns the players that can fill the match, the match that is already filled can have only its player
+func getPairCandidates(match *placeholderMatch, users []database.SessionUserInfo) (candidates []database.SessionUserInfo) {
+	if len(match.name) > 0 {
+		return []database.SessionUserInfo{{UserId: match.userId}}
+	}
+
+	for i := range users {
+		if canBeNamedByMatch(&users[i], match) {
+			candidates = append(candidates, users[i])
+		}
+	}
+	return
+}
+
+// names the first pair of players in the draw order that follows the pairing rules
+func fillOppositePair(pair [2]*placeholderMatch, users *[]database.SessionUserInfo, boundMatches map[placeholderBinding]*placeholderMatch, constraints *agreedPairConstraints) {
+	for _, match := range pair {
+		if isBound(match.binding) {
+			if boundMatch, isFound := boundMatches[match.binding]; isFound {
+				match.name = boundMatch.name
+				match.userId = boundMatch.userId
+			}
+		}
+	}
+
+	if len(pair[0].name) > 0 && len(pair[1].name) > 0 {
+		return
+	}
+
+	var pairUsers [2]database.SessionUserInfo
+	isFound := false
+	candidates := getPairCandidates(pair[1], *users)
+	for _, user1 := range getPairCandidates(pair[0], *users) {
+		for _, user2 := range candidates {
+			if user1.UserId != user2.UserId && constraints.canPair(user1.UserId, user2.UserId) {
+				pairUsers = [2]database.SessionUserInfo{user1, user2}
+				isFound = true
+				break
+			}
+		}
+		if isFound {
+			break
+		}
+	}
+
+	for side, match := range pair {
+		if len(match.name) > 0 {
+			continue
+		}
+
+		if user, isUserFound := getAndRemoveParticipatingUserById(users, pairUsers[side].UserId, match); isFound && isUserFound {
+			match.name = user.Name
+			match.userId = user.UserId
+		} else {
+			match.name = "[no match]"
+		}
+
+		if isBound(match.binding) {
+			boundMatches[match.binding] = match
+		}
+	}
+}
+
+func getPairConstraintName(kind int, trans i18n.TranslateFunc) string {
+	switch kind {
+	case database.PairConstraintNever:
+		return trans("pair_constraint_never")
+	case database.PairConstraintOnly:
+		return trans("pair_constraint_only")
+	}
+	return trans("pair_constraint_none")
+}
+
+// lists the rules the player chose and the rules the other players chose for them
+func GetPairConstraintsMessage(staticData *processing.StaticProccessStructs, sessionId int64, userId int64, trans i18n.TranslateFunc) string {
+	db := GetDb(staticData)
+	constraints := db.GetSessionPairConstraints(sessionId)
+
+	lines := []string{trans("pair_constraints_title")}
+	for _, user := range db.GetUsersInSessionInfo(sessionId) {
+		if user.UserId == userId {
+			continue
+		}
+
+		kind := GetPairConstraint(constraints, userId, user.UserId)
+		partnerKind := GetPairConstraint(constraints, user.UserId, userId)
+		templateData := map[string]interface{}{
+			"Name":        user.Name,
+			"Rule":        getPairConstraintName(kind, trans),
+			"PartnerRule": getPairConstraintName(partnerKind, trans),
+		}
+
+		if kind != database.PairConstraintNone && kind == partnerKind {
+			lines = append(lines, trans("pair_constraint_agreed_line", templateData))
+		} else if kind != database.PairConstraintNone {
+			lines = append(lines, trans("pair_constraint_waiting_line", templateData))
+		} else if partnerKind != database.PairConstraintNone {
+			lines = append(lines, trans("pair_constraint_proposed_line", templateData))
+		}
+	}
+
+	if len(lines) == 1 {
+		lines = append(lines, trans("pair_constraints_empty"))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// the rule applies only after the partner chooses the same one, so the partner is asked about it
+func SetPairConstraint(staticData *processing.StaticProccessStructs, sessionId int64, userId int64, partnerUserId int64, kind int) (isSucceeded bool) {
+	db := GetDb(staticData)
+
+	partner, isFound := findUserInfo(db.GetUsersInSessionInfo(sessionId), partnerUserId)
+	if !isFound || partnerUserId == userId || kind < database.PairConstraintNone || kind > database.PairConstraintOnly {
+		return false
+	}
+
+	db.SetPairConstraint(sessionId, userId, partnerUserId, kind)
+
+	partnerKind := GetPairConstraint(db.GetSessionPairConstraints(sessionId), partnerUserId, userId)
+	if kind == database.PairConstraintNone && partnerKind == database.PairConstraintNone {
+		return true
+	}
+
+	trans := FindTransFunction(partnerUserId, staticData)
+	templateData := map[string]interface{}{
+		"Name": db.GetUserName(userId),
+		"Rule": getPairConstraintName(kind, trans),
+	}
+
+	switch kind {
+	case partnerKind:
+		sendMessageToPlayer(staticData, &partner, "pair_constraint_agreed", templateData)
+	case database.PairConstraintNone:
+		sendMessageToPlayer(staticData, &partner, "pair_constraint_removed", templateData)
+	default:
+		sendMessageToPlayer(staticData, &partner, "pair_constraint_proposed", templateData)
+		// Telegram players can agree right away
+		if !partner.IsWebUser {
+			staticData.Chat.SendDialog(partner.ChatId, staticData.MakeDialogFn("pc", partnerUserId, trans, staticData, nil), 0)
+		}
+	}
+	return true
+}
@@ -0,0 +1,270 @@
+package staticFunctions
+
+import (
+	"github.com/gameraccoon/telegram-bot-skeleton/processing"
+	static "github.com/gameraccoon/telegram-the-king-says-bot/staticData"
+	"html"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const maxSessionPlaceholdersLength = 1000
+
+type sessionPlaceholdersCacheEntry struct {
+	source       string
+	language     string
+	placeholders *static.PlaceholderInfos
+}
+
+// compiled placeholders set by the hosts, to not compile them on every reveal
+var sessionPlaceholdersCache = struct {
+	mutex   sync.Mutex
+	entries map[int64]sessionPlaceholdersCacheEntry
+}{
+	entries: make(map[int64]sessionPlaceholdersCacheEntry),
+}
+
+// the placeholders that the host can set depend on the configured groups and teams
+type placeholderSetLayout struct {
+	groups     []static.GroupInfo
+	teamsCount int
+}
+
+func getPlaceholderSetLayout(staticData *processing.StaticProccessStructs) *placeholderSetLayout {
+	return &placeholderSetLayout{
+		groups:     GetGroups(staticData),
+		teamsCount: len(getPlaceholders(staticData).Teams),
+	}
+}
+
+const teamPlaceholderKeyPrefix = "team"
+
+// the names of the placeholders that the host can set, in the order they are shown
+// each participation group has its placeholders under the key of the group
+func (layout *placeholderSetLayout) getKeys() []string {
+	keys := []string{"common"}
+	for _, group := range layout.groups {
+		keys = append(keys, group.Key)
+	}
+	keys = append(keys, "opposite1", "opposite2")
+	for team := 1; team <= layout.teamsCount; team++ {
+		keys = append(keys, teamPlaceholderKeyPrefix+strconv.Itoa(team))
+	}
+	return append(keys, "otherteam", "others", "everyone")
+}
+
+func (layout *placeholderSetLayout) getPlaceholderByKey(placeholders *static.PlaceholderInfos, key string) *static.PlaceholderInfo {
+	for _, group := range layout.groups {
+		if group.Key != key {
+			continue
+		}
+
+		if placeholders.Groups == nil {
+			placeholders.Groups = make(map[string]*static.PlaceholderInfo)
+		}
+		if _, isFound := placeholders.Groups[key]; !isFound {
+			placeholders.Groups[key] = &static.PlaceholderInfo{}
+		}
+		return placeholders.Groups[key]
+	}
+
+	if strings.HasPrefix(key, teamPlaceholderKeyPrefix) {
+		team, err := strconv.Atoi(key[len(teamPlaceholderKeyPrefix):])
+		if err != nil || team < 1 || team > layout.teamsCount {
+			return nil
+		}
+
+		for len(placeholders.Teams) < team {
+			placeholders.Teams = append(placeholders.Teams, static.PlaceholderInfo{})
+		}
+		return &placeholders.Teams[team-1]
+	}
+
+	switch key {
+	case "common":
+		return &placeholders.Common
+	case "opposite1":
+		return &placeholders.Opposite[0]
+	case "opposite2":
+		return &placeholders.Opposite[1]
+	case "otherteam":
+		return &placeholders.OtherTeam
+	case "others":
+		return &placeholders.Others
+	case "everyone":
+		return &placeholders.Everyone
+	}
+	return nil
+}
+
+// parses lines like "female: 👸 $q", returns the first line that can't be parsed if any
+func (layout *placeholderSetLayout) parse(text string) (placeholders *static.PlaceholderInfos, invalidLine string) {
+	placeholders = &static.PlaceholderInfos{}
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		keyAndValues := strings.SplitN(line, ":", 2)
+		if len(keyAndValues) != 2 {
+			return nil, line
+		}
+
+		placeholder := layout.getPlaceholderByKey(placeholders, strings.ToLower(strings.TrimSpace(keyAndValues[0])))
+		values := strings.Fields(keyAndValues[1])
+		if placeholder == nil || len(values) == 0 {
+			return nil, line
+		}
+
+		placeholder.Values = append(placeholder.Values, values...)
+	}
+	return
+}
+
+func (layout *placeholderSetLayout) format(placeholders *static.PlaceholderInfos) string {
+	keys := layout.getKeys()
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		lines = append(lines, key+": "+strings.Join(layout.getPlaceholderByKey(placeholders, key).Values, " "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func getSessionLanguage(staticData *processing.StaticProccessStructs, sessionId int64) string {
+	db := GetDb(staticData)
+
+	config, configCastSuccess := staticData.Config.(static.StaticConfiguration)
+	if !configCastSuccess {
+		config = static.StaticConfiguration{}
+	}
+
+	// the session is played in the language of its host
+	if hostUserId, isFound := db.GetSessionHost(sessionId); isFound {
+		if language := db.GetUserLanguage(hostUserId); len(language) > 0 {
+			return getClosestLang(&config, language)
+		}
+	}
+	return config.DefaultLanguage
+}
+
+func getLanguagePlaceholders(staticData *processing.StaticProccessStructs, language string) *static.PlaceholderInfos {
+	return getPlaceholders(staticData).ForLanguage(language)
+}
+
+// returns the placeholders set by the host, or the ones for the session language
+func GetSessionPlaceholders(staticData *processing.StaticProccessStructs, sessionId int64) *static.PlaceholderInfos {
+	language := getSessionLanguage(staticData, sessionId)
+	base := getLanguagePlaceholders(staticData, language)
+
+	source := GetDb(staticData).GetSessionPlaceholders(sessionId)
+	if len(source) == 0 {
+		return base
+	}
+
+	sessionPlaceholdersCache.mutex.Lock()
+	defer sessionPlaceholdersCache.mutex.Unlock()
+
+	if entry, isFound := sessionPlaceholdersCache.entries[sessionId]; isFound && entry.source == source && entry.language == language {
+		return entry.placeholders
+	}
+
+	placeholders, invalidLine := getPlaceholderSetLayout(staticData).parse(source)
+	if placeholders == nil {
+		// should never happen since the text was checked when it was set
+		log.Printf("Can't parse placeholders of session %d, line: %s", sessionId, invalidLine)
+		return base
+	}
+	if err := placeholders.CheckOppositePairs(); err != nil {
+		log.Printf("Placeholders of session %d are invalid: %s", sessionId, err.Error())
+		return base
+	}
+
+	placeholders.Inherit(base)
+	// can't fail since there are no languages in the set and the groups are checked in the base set
+	_ = placeholders.Compile(nil, nil)
+
+	sessionPlaceholdersCache.entries[sessionId] = sessionPlaceholdersCacheEntry{
+		source:       source,
+		language:     language,
+		placeholders: placeholders,
+	}
+	return placeholders
+}
+
+func forgetSessionPlaceholders(sessionId int64) {
+	sessionPlaceholdersCache.mutex.Lock()
+	defer sessionPlaceholdersCache.mutex.Unlock()
+
+	delete(sessionPlaceholdersCache.entries, sessionId)
+}
+
+// a safety net for the entries of the sessions that were deleted without forgetSessionPlaceholders
+func forgetEndedSessionsPlaceholders(staticData *processing.StaticProccessStructs) {
+	db := GetDb(staticData)
+
+	sessionPlaceholdersCache.mutex.Lock()
+	defer sessionPlaceholdersCache.mutex.Unlock()
+
+	for sessionId := range sessionPlaceholdersCache.entries {
+		if !db.DoesSessionExist(sessionId) {
+			delete(sessionPlaceholdersCache.entries, sessionId)
+		}
+	}
+}
+
+func AskForSessionPlaceholders(data *processing.ProcessData, sessionId int64) {
+	data.SendMessage(data.Trans("enter_session_placeholders", map[string]interface{}{
+		"Placeholders": html.EscapeString(getPlaceholderSetLayout(data.Static).format(GetSessionPlaceholders(data.Static, sessionId))),
+	}), true)
+	data.Static.SetUserStateTextProcessor(data.UserId, &processing.AwaitingTextProcessorData{
+		ProcessorId:  "sessionPlaceholders",
+		AdditionalId: sessionId,
+	})
+}
+
+// "-" resets the placeholders of the session to the default ones
+func SetSessionPlaceholders(data *processing.ProcessData, sessionId int64, text string) {
+	db := GetDb(data.Static)
+	text = strings.TrimSpace(text)
+
+	if text == "-" {
+		db.SetSessionPlaceholders(sessionId, "")
+		forgetSessionPlaceholders(sessionId)
+		data.SendMessage(data.Trans("session_placeholders_reset"), true)
+		return
+	}
+
+	if len(text) > maxSessionPlaceholdersLength {
+		data.SendMessage(data.Trans("session_placeholders_too_long"), true)
+		AskForSessionPlaceholders(data, sessionId)
+		return
+	}
+
+	layout := getPlaceholderSetLayout(data.Static)
+	placeholders, invalidLine := layout.parse(text)
+	if placeholders == nil {
+		data.SendMessage(data.Trans("invalid_session_placeholders", map[string]interface{}{
+			"Line": html.EscapeString(invalidLine),
+			"Keys": strings.Join(layout.getKeys(), ", "),
+		}), true)
+		AskForSessionPlaceholders(data, sessionId)
+		return
+	}
+
+	// otherwise the placeholder that the host set would be silently replaced by the default pair
+	if placeholders.CheckOppositePairs() != nil {
+		data.SendMessage(data.Trans("session_placeholders_not_paired"), true)
+		AskForSessionPlaceholders(data, sessionId)
+		return
+	}
+
+	db.SetSessionPlaceholders(sessionId, text)
+	forgetSessionPlaceholders(sessionId)
+	data.SendMessage(data.Trans("session_placeholders_set", map[string]interface{}{
+		"Placeholders": html.EscapeString(layout.format(GetSessionPlaceholders(data.Static, sessionId))),
+	}), true)
+}
@@ -0,0 +1,31 @@
+package staticFunctions
+
+import (
+	static "github.com/gameraccoon/telegram-the-king-says-bot/staticData"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSessionPlaceholdersOppositePairs(t *testing.T) {
+	layout := &placeholderSetLayout{
+		groups: []static.GroupInfo{{Key: "female"}, {Key: "male"}},
+	}
+
+	testCases := []struct {
+		text     string
+		isPaired bool
+	}{
+		{"common: 🎲", true},
+		{"opposite1: 🌞\nopposite2: 🌚", true},
+		{"opposite1: 🌞", false},
+		{"common: 🎲\nopposite2: 🌚", false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.text, func(t *testing.T) {
+			placeholders, _ := layout.parse(testCase.text)
+			require.NotNil(t, placeholders)
+			require.Equal(t, testCase.isPaired, placeholders.CheckOppositePairs() == nil)
+		})
+	}
+}
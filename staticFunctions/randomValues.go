@@ -0,0 +1,130 @@
+package staticFunctions
+
+import (
+	"github.com/nicksnyder/go-i18n/i18n"
+	"html"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+const (
+	playersCountToken = "#"
+	choiceSeparator   = "|"
+	rangeSeparator    = "-"
+	// the numbers come from the players, so they are limited to keep the range arithmetic safe
+	maxRandomRangeValue = 1000000
+)
+
+func getRangeBound(bound string, playersCount int) (value int, isPlayersCount bool, isValid bool) {
+	bound = strings.TrimSpace(bound)
+	if bound == playersCountToken {
+		return playersCount, true, true
+	}
+
+	value, err := strconv.Atoi(bound)
+	return value, false, err == nil && value >= 0 && value <= maxRandomRangeValue
+}
+
+// expands the content of one pair of braces: {#}, {a|b|c} or {10-60}
+func expandRandomValue(content string, playersCount int) (value string, isValid bool) {
+	if strings.TrimSpace(content) == playersCountToken {
+		return strconv.Itoa(playersCount), true
+	}
+
+	if strings.Contains(content, choiceSeparator) {
+		options := strings.Split(content, choiceSeparator)
+		for _, option := range options {
+			if len(strings.TrimSpace(option)) == 0 {
+				return
+			}
+		}
+		return strings.TrimSpace(options[rand.Intn(len(options))]), true
+	}
+
+	bounds := strings.Split(content, rangeSeparator)
+	if len(bounds) != 2 {
+		return
+	}
+
+	from, isFromPlayersCount, isFromValid := getRangeBound(bounds[0], playersCount)
+	to, isToPlayersCount, isToValid := getRangeBound(bounds[1], playersCount)
+	if !isFromValid || !isToValid {
+		return
+	}
+
+	if from > to {
+		// the number of players is only known when the dare is revealed, so {3-#} is fine with two players
+		if !isFromPlayersCount && !isToPlayersCount {
+			return
+		}
+		from, to = to, from
+	}
+
+	if to > maxRandomRangeValue {
+		// the players count can't be that big, but the range still shouldn't overflow
+		return
+	}
+	return strconv.Itoa(from + rand.Intn(to-from+1)), true
+}
+
+// replaces all the random values in the text, the invalid ones are kept as they are
+// and the first of them is returned as invalidFragment
+func expandRandomValues(command string, playersCount int) (result string, invalidFragment string) {
+	var builder strings.Builder
+	rest := command
+
+	setInvalid := func(fragment string) {
+		if len(invalidFragment) == 0 {
+			invalidFragment = fragment
+		}
+	}
+
+	for {
+		openIdx := strings.IndexAny(rest, "{}")
+		if openIdx == -1 {
+			builder.WriteString(rest)
+			break
+		}
+
+		if rest[openIdx] == '}' {
+			setInvalid("}")
+			builder.WriteString(rest[:openIdx+1])
+			rest = rest[openIdx+1:]
+			continue
+		}
+
+		closeIdx := strings.IndexAny(rest[openIdx+1:], "{}")
+		if closeIdx == -1 || rest[openIdx+1+closeIdx] == '{' {
+			setInvalid("{")
+			builder.WriteString(rest[:openIdx+1])
+			rest = rest[openIdx+1:]
+			continue
+		}
+		closeIdx += openIdx + 1
+
+		content := rest[openIdx+1 : closeIdx]
+		if value, isValid := expandRandomValue(content, playersCount); isValid {
+			builder.WriteString(rest[:openIdx])
+			builder.WriteString(value)
+		} else {
+			setInvalid(rest[openIdx : closeIdx+1])
+			builder.WriteString(rest[:closeIdx+1])
+		}
+		rest = rest[closeIdx+1:]
+	}
+
+	result = builder.String()
+	return
+}
+
+func FindInvalidRandomValue(command string) (fragment string, isFound bool) {
+	_, fragment = expandRandomValues(command, 0)
+	return fragment, len(fragment) > 0
+}
+
+func GetInvalidRandomValueMessage(fragment string, trans i18n.TranslateFunc) string {
+	return trans("invalid_random_value", map[string]interface{}{
+		"Fragment": html.EscapeString(fragment),
+	})
+}
@@ -0,0 +1,122 @@
+package staticFunctions
+
+import (
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"testing"
+)
+
+func TestExpandRandomValueRanges(t *testing.T) {
+	testCases := []struct {
+		content      string
+		playersCount int
+		from         int
+		to           int
+		isValid      bool
+	}{
+		{"10-60", 5, 10, 60, true},
+		{" 3 - 3 ", 5, 3, 3, true},
+		{"0-1000000", 5, 0, 1000000, true},
+		{"1-#", 5, 1, 5, true},
+		{"#-10", 5, 5, 10, true},
+		// the players count is known only when the dare is revealed, so the bounds can swap
+		{"3-#", 2, 2, 3, true},
+		{"60-10", 5, 0, 0, false},
+		{"0-1000001", 5, 0, 0, false},
+		{"0-9223372036854775807", 5, 0, 0, false},
+		{"-5-10", 5, 0, 0, false},
+		{"1-2-3", 5, 0, 0, false},
+		{"a-b", 5, 0, 0, false},
+		{"10", 5, 0, 0, false},
+		{"", 5, 0, 0, false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.content, func(t *testing.T) {
+			assert := require.New(t)
+			for i := 0; i < 100; i++ {
+				value, isValid := expandRandomValue(testCase.content, testCase.playersCount)
+				assert.Equal(testCase.isValid, isValid)
+				if !testCase.isValid {
+					return
+				}
+
+				number, err := strconv.Atoi(value)
+				assert.Nil(err)
+				assert.GreaterOrEqual(number, testCase.from)
+				assert.LessOrEqual(number, testCase.to)
+			}
+		})
+	}
+}
+
+func TestExpandRandomValueChoices(t *testing.T) {
+	testCases := []struct {
+		content string
+		options []string
+		isValid bool
+	}{
+		{"truth|dare|drink", []string{"truth", "dare", "drink"}, true},
+		{" a | b ", []string{"a", "b"}, true},
+		{"a||b", nil, false},
+		{"a|", nil, false},
+		{"|", nil, false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.content, func(t *testing.T) {
+			assert := require.New(t)
+			for i := 0; i < 100; i++ {
+				value, isValid := expandRandomValue(testCase.content, 3)
+				assert.Equal(testCase.isValid, isValid)
+				if !testCase.isValid {
+					return
+				}
+				assert.Contains(testCase.options, value)
+			}
+		})
+	}
+}
+
+func TestExpandRandomValues(t *testing.T) {
+	testCases := []struct {
+		command         string
+		playersCount    int
+		result          string
+		invalidFragment string
+	}{
+		{"no random values", 4, "no random values", ""},
+		{"{#} players", 4, "4 players", ""},
+		{"{ # } players", 4, "4 players", ""},
+		{"{1-1} and {x|x}", 4, "1 and x", ""},
+		{"{60-10} sips", 4, "{60-10} sips", "{60-10}"},
+		{"{0-9223372036854775807} sips", 4, "{0-9223372036854775807} sips", "{0-9223372036854775807}"},
+		{"{} then {#}", 4, "{} then 4", "{}"},
+		{"open {# and {#}", 4, "open {# and 4", "{"},
+		{"close } and {#}", 4, "close } and 4", "}"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.command, func(t *testing.T) {
+			assert := require.New(t)
+			result, invalidFragment := expandRandomValues(testCase.command, testCase.playersCount)
+			assert.Equal(testCase.result, result)
+			assert.Equal(testCase.invalidFragment, invalidFragment)
+		})
+	}
+}
+
+func TestFindInvalidRandomValue(t *testing.T) {
+	assert := require.New(t)
+
+	_, isFound := FindInvalidRandomValue("drink {1-#} sips")
+	assert.False(isFound)
+
+	fragment, isFound := FindInvalidRandomValue("drink {0-9223372036854775807} sips")
+	assert.True(isFound)
+	assert.Equal("{0-9223372036854775807}", fragment)
+
+	fragment, isFound = FindInvalidRandomValue("drink {5-1} sips")
+	assert.True(isFound)
+	assert.Equal("{5-1}", fragment)
+}
@@ -0,0 +1,64 @@
+package staticFunctions
+
+import (
+	"github.com/gameraccoon/telegram-bot-skeleton/processing"
+	"github.com/nicksnyder/go-i18n/i18n"
+	"strings"
+)
+
+// a player as the other players of the session see them
+type RosterPlayer struct {
+	UserId   int64
+	Name     string
+	Groups   string
+	IsHost   bool
+	IsPaused bool
+	// the team with its placeholder, empty if the player is not in a team
+	Team string
+}
+
+func GetSessionRoster(staticData *processing.StaticProccessStructs, sessionId int64, trans i18n.TranslateFunc) (roster []RosterPlayer) {
+	db := GetDb(staticData)
+
+	hostUserId, _ := db.GetSessionHost(sessionId)
+	placeholders := GetSessionPlaceholders(staticData, sessionId)
+
+	for _, user := range db.GetUsersInSessionInfo(sessionId) {
+		player := RosterPlayer{
+			UserId:   user.UserId,
+			Name:     user.Name,
+			Groups:   GetGroupNames(staticData, user.Groups, trans),
+			IsHost:   user.UserId == hostUserId,
+			IsPaused: user.IsPaused,
+		}
+		if user.Team != 0 {
+			player.Team = getTeamName(placeholders, user.Team, trans)
+		}
+		roster = append(roster, player)
+	}
+	return
+}
+
+// one line for each player of the session
+func GetRosterMessage(staticData *processing.StaticProccessStructs, sessionId int64, trans i18n.TranslateFunc) string {
+	lines := []string{trans("roster_title")}
+	for i, player := range GetSessionRoster(staticData, sessionId, trans) {
+		details := []string{player.Groups}
+		if player.IsHost {
+			details = append(details, trans("roster_host"))
+		}
+		if len(player.Team) > 0 {
+			details = append(details, player.Team)
+		}
+		if player.IsPaused {
+			details = append(details, trans("roster_paused"))
+		}
+
+		lines = append(lines, trans("roster_line", map[string]interface{}{
+			"Number":  i + 1,
+			"Name":    player.Name,
+			"Details": strings.Join(details, ", "),
+		}))
+	}
+	return strings.Join(lines, "\n")
+}
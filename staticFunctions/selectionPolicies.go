@@ -0,0 +1,109 @@
+package staticFunctions
+
+import (
+	"fmt"
+	"github.com/gameraccoon/telegram-the-king-says-bot/database"
+	"math/rand"
+	"sort"
+)
+
+// orders the players of the session in the order they should be named in a dare
+type selectionPolicy func(users []database.SessionUserInfo) []database.SessionUserInfo
+
+func getSelectionPolicy(policy int) selectionPolicy {
+	switch policy {
+	case database.SelectionPolicyUniform:
+		return func(users []database.SessionUserInfo) []database.SessionUserInfo {
+			return weightedShuffle(users, getUniformWeight)
+		}
+	case database.SelectionPolicyLinear:
+		return func(users []database.SessionUserInfo) []database.SessionUserInfo {
+			return weightedShuffle(users, getLinearWeight)
+		}
+	case database.SelectionPolicyRoundRobin:
+		return roundRobinShuffle
+	default:
+		return func(users []database.SessionUserInfo) []database.SessionUserInfo {
+			return weightedShuffle(users, getExponentialWeight)
+		}
+	}
+}
+
+func getUniformWeight(user *database.SessionUserInfo) int {
+	return 1
+}
+
+// the weight doubles for every round the player didn't participate in
+func getExponentialWeight(user *database.SessionUserInfo) int {
+	weightPower := user.CurrentSessionIdleCount
+	if weightPower > 31 {
+		weightPower = 31
+	}
+
+	weight := 1 << weightPower
+
+	if weight <= 0 {
+		panic("User weight can never be zero or lower")
+	}
+	return weight
+}
+
+// the weight grows by one for every round the player didn't participate in
+func getLinearWeight(user *database.SessionUserInfo) int {
+	return user.CurrentSessionIdleCount + 1
+}
+
+func deleteUnordered(s *[]database.SessionUserInfo, i int) {
+	(*s)[i] = (*s)[len(*s)-1]
+	(*s) = (*s)[:len(*s)-1]
+}
+
+func weightedShuffle(users []database.SessionUserInfo, getUserWeight func(*database.SessionUserInfo) int) []database.SessionUserInfo {
+	if len(users) <= 1 {
+		return users
+	}
+
+	usersToDrawFrom := make([]database.SessionUserInfo, len(users))
+	copy(usersToDrawFrom, users)
+
+	result := []database.SessionUserInfo{}
+
+	sum := 0
+	for _, user := range usersToDrawFrom {
+		sum += getUserWeight(&user)
+	}
+
+	for len(usersToDrawFrom) > 1 {
+		weight := rand.Intn(sum)
+		for i, user := range usersToDrawFrom {
+			userWeight := getUserWeight(&user)
+			if weight < userWeight {
+				result = append(result, user)
+				deleteUnordered(&usersToDrawFrom, i)
+				sum -= userWeight
+				break
+			}
+			weight -= userWeight
+		}
+	}
+
+	if sum != getUserWeight(&usersToDrawFrom[0]) {
+		panic(fmt.Sprintf("The final list doesn't contain some of the records, missing weight %d", sum-getUserWeight(&usersToDrawFrom[0])))
+	}
+	result = append(result, usersToDrawFrom[0])
+
+	return result
+}
+
+// the players who waited the longest go first, so no one is named twice until everyone was named
+// the idle count of a player is reset when they are named and grows for everyone else
+func roundRobinShuffle(users []database.SessionUserInfo) []database.SessionUserInfo {
+	result := make([]database.SessionUserInfo, len(users))
+	copy(result, users)
+
+	rand.Shuffle(len(result), func(i, j int) { result[i], result[j] = result[j], result[i] })
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].CurrentSessionIdleCount > result[j].CurrentSessionIdleCount
+	})
+	return result
+}
@@ -0,0 +1,120 @@
+package staticFunctions
+
+import (
+	"github.com/gameraccoon/telegram-the-king-says-bot/database"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+const (
+	drawsCount = 100000
+	// with this many draws the measured share is much closer than this to the expected one
+	shareTolerance = 0.01
+)
+
+func makeUsersWithIdleCounts(idleCounts ...int) (users []database.SessionUserInfo) {
+	for i, idleCount := range idleCounts {
+		users = append(users, database.SessionUserInfo{
+			UserId:                  int64(i + 1),
+			CurrentSessionIdleCount: idleCount,
+		})
+	}
+	return
+}
+
+// returns how often each player was drawn first
+func measureFirstDrawShares(policy selectionPolicy, users []database.SessionUserInfo) map[int64]float64 {
+	counts := make(map[int64]int)
+	for i := 0; i < drawsCount; i++ {
+		counts[policy(users)[0].UserId]++
+	}
+
+	shares := make(map[int64]float64)
+	for userId, count := range counts {
+		shares[userId] = float64(count) / drawsCount
+	}
+	return shares
+}
+
+func requireSharesProportionalToWeights(t *testing.T, policy selectionPolicy, idleCounts []int, weights []int) {
+	assert := require.New(t)
+
+	users := makeUsersWithIdleCounts(idleCounts...)
+	shares := measureFirstDrawShares(policy, users)
+
+	weightsSum := 0
+	for _, weight := range weights {
+		weightsSum += weight
+	}
+
+	for i, user := range users {
+		expectedShare := float64(weights[i]) / float64(weightsSum)
+		assert.InDelta(expectedShare, shares[user.UserId], shareTolerance, "player with idle count %d", idleCounts[i])
+	}
+}
+
+func TestUniformSelectionPolicy(t *testing.T) {
+	requireSharesProportionalToWeights(t, getSelectionPolicy(database.SelectionPolicyUniform), []int{0, 1, 2, 3}, []int{1, 1, 1, 1})
+}
+
+func TestExponentialSelectionPolicy(t *testing.T) {
+	requireSharesProportionalToWeights(t, getSelectionPolicy(database.SelectionPolicyExponential), []int{0, 1, 2, 3}, []int{1, 2, 4, 8})
+}
+
+func TestLinearSelectionPolicy(t *testing.T) {
+	requireSharesProportionalToWeights(t, getSelectionPolicy(database.SelectionPolicyLinear), []int{0, 1, 2, 3}, []int{1, 2, 3, 4})
+}
+
+func TestRoundRobinSelectionPolicy(t *testing.T) {
+	requireSharesProportionalToWeights(t, getSelectionPolicy(database.SelectionPolicyRoundRobin), []int{0, 1, 3, 3}, []int{0, 0, 1, 1})
+}
+
+func TestExponentialWeightIsCapped(t *testing.T) {
+	assert := require.New(t)
+
+	users := makeUsersWithIdleCounts(31, 100)
+	assert.Equal(getExponentialWeight(&users[0]), getExponentialWeight(&users[1]))
+}
+
+// plays many rounds naming one player each time and updates the idle counts the way the game does it
+func simulateRounds(policy selectionPolicy, users []database.SessionUserInfo, roundsCount int) (drawnUserIds []int64) {
+	for round := 0; round < roundsCount; round++ {
+		drawnUserId := policy(users)[0].UserId
+		drawnUserIds = append(drawnUserIds, drawnUserId)
+
+		for i := range users {
+			if users[i].UserId == drawnUserId {
+				users[i].CurrentSessionIdleCount = 0
+			} else {
+				users[i].CurrentSessionIdleCount++
+			}
+		}
+	}
+	return
+}
+
+func TestRoundRobinNamesEveryoneBeforeRepeating(t *testing.T) {
+	assert := require.New(t)
+
+	const playersCount = 5
+	users := makeUsersWithIdleCounts(0, 0, 0, 0, 0)
+	drawnUserIds := simulateRounds(getSelectionPolicy(database.SelectionPolicyRoundRobin), users, playersCount*20)
+
+	for cycleStart := 0; cycleStart < len(drawnUserIds); cycleStart += playersCount {
+		cycle := make(map[int64]bool)
+		for _, userId := range drawnUserIds[cycleStart : cycleStart+playersCount] {
+			assert.False(cycle[userId], "player %d was named twice in one cycle", userId)
+			cycle[userId] = true
+		}
+	}
+}
+
+func TestSelectionPoliciesKeepAllPlayers(t *testing.T) {
+	assert := require.New(t)
+
+	users := makeUsersWithIdleCounts(0, 5, 1, 40, 2)
+	for _, policy := range []int{database.SelectionPolicyExponential, database.SelectionPolicyUniform, database.SelectionPolicyLinear, database.SelectionPolicyRoundRobin} {
+		result := getSelectionPolicy(policy)(users)
+		assert.ElementsMatch(users, result, "policy %d", policy)
+	}
+}
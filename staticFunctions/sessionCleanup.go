@@ -0,0 +1,46 @@
+package staticFunctions
+
+import (
+	"github.com/gameraccoon/telegram-bot-skeleton/processing"
+	"log"
+	"time"
+)
+
+const (
+	sessionCleanupInterval = 5 * time.Minute
+	// the players of web hosted sessions can just close the page, so these sessions are ended even when inactivityTimeout is zero
+	webHostedSessionInactivityTimeout = 24 * time.Hour
+)
+
+// ends sessions that don't have Telegram players, web hosted sessions that were abandoned by their players,
+// and (if inactivityTimeout is not zero) sessions where nobody did anything for longer than inactivityTimeout
+// the cached data of the sessions that ended in any way is dropped too
+func CleanUpAbandonedSessions(staticData *processing.StaticProccessStructs, inactivityTimeout time.Duration) {
+	db := GetDb(staticData)
+
+	for _, sessionId := range db.GetSessionsWithoutTelegramUsers() {
+		log.Printf("Ending session %d that doesn't have Telegram players", sessionId)
+		endSessionWithMessage(staticData, sessionId, "session_ended_no_telegram_players")
+	}
+
+	for _, sessionId := range db.GetWebHostedSessionsInactiveSince(time.Now().Add(-webHostedSessionInactivityTimeout).Unix()) {
+		log.Printf("Ending abandoned web hosted session %d", sessionId)
+		endSessionWithMessage(staticData, sessionId, "session_ended_inactive")
+	}
+
+	if inactivityTimeout > 0 {
+		for _, sessionId := range db.GetSessionsInactiveSince(time.Now().Add(-inactivityTimeout).Unix()) {
+			log.Printf("Ending inactive session %d", sessionId)
+			endSessionWithMessage(staticData, sessionId, "session_ended_inactive")
+		}
+	}
+
+	forgetEndedSessionsPlaceholders(staticData)
+}
+
+func CleanUpAbandonedSessionsPeriodically(staticData *processing.StaticProccessStructs, inactivityTimeout time.Duration) {
+	for {
+		CleanUpAbandonedSessions(staticData, inactivityTimeout)
+		time.Sleep(sessionCleanupInterval)
+	}
+}
@@ -0,0 +1,111 @@
+package staticFunctions
+
+import (
+	"github.com/gameraccoon/telegram-bot-skeleton/processing"
+	"github.com/gameraccoon/telegram-the-king-says-bot/database"
+)
+
+func findUserInfo(users []database.SessionUserInfo, userId int64) (user database.SessionUserInfo, isFound bool) {
+	for _, user := range users {
+		if user.UserId == userId {
+			return user, true
+		}
+	}
+	return
+}
+
+func sendMessageToPlayer(staticData *processing.StaticProccessStructs, user *database.SessionUserInfo, textId string, templateData map[string]interface{}) {
+	trans := FindTransFunction(user.UserId, staticData)
+	sendTextToPlayer(staticData, user, trans(textId, templateData))
+}
+
+func sendTextToPlayer(staticData *processing.StaticProccessStructs, user *database.SessionUserInfo, message string) {
+	if user.IsWebUser {
+		GetDb(staticData).AddWebMessage(user.UserId, message, 10)
+		notifyWebPlayer(user.UserId)
+	} else {
+		staticData.Chat.SendMessage(user.ChatId, message, 0, true)
+	}
+}
+
+func removePlayerFromSession(staticData *processing.StaticProccessStructs, user *database.SessionUserInfo, textId string) (isSessionAbandoned bool) {
+	db := GetDb(staticData)
+	if user.IsWebUser {
+		// the web page will find out on the next update that the player is gone and show the message
+		trans := FindTransFunction(user.UserId, staticData)
+		rememberRemovedWebPlayer(user.ChatId, trans(textId))
+		isSessionAbandoned = db.RemoveWebUser(user.ChatId)
+		notifyWebPlayer(user.UserId)
+	} else {
+		_, _, isSessionAbandoned = db.LeaveSession(user.UserId)
+		trans := FindTransFunction(user.UserId, staticData)
+		staticData.Chat.SendMessage(user.ChatId, trans(textId), 0, true)
+		SendNoSessionDialogToSomeone(user.UserId, user.ChatId, trans, staticData)
+	}
+	return
+}
+
+// updates the dialogs of the session that a player left, or ends the session if nobody who can keep it going is left
+func UpdateLeftSession(staticData *processing.StaticProccessStructs, sessionId int64, isSessionAbandoned bool) {
+	if isSessionAbandoned {
+		endSessionWithMessage(staticData, sessionId, "session_ended_no_telegram_players")
+	} else {
+		UpdateSessionDialogs(sessionId, staticData)
+	}
+}
+
+func KickPlayer(staticData *processing.StaticProccessStructs, sessionId int64, userId int64) (isSucceeded bool) {
+	db := GetDb(staticData)
+	user, isFound := findUserInfo(db.GetUsersInSessionInfo(sessionId), userId)
+	if !isFound {
+		return false
+	}
+
+	isSessionAbandoned := removePlayerFromSession(staticData, &user, "kicked_from_session")
+	UpdateLeftSession(staticData, sessionId, isSessionAbandoned)
+	return true
+}
+
+func TransferSessionHost(staticData *processing.StaticProccessStructs, sessionId int64, newHostUserId int64) (isSucceeded bool) {
+	db := GetDb(staticData)
+	user, isFound := findUserInfo(db.GetUsersInSessionInfo(sessionId), newHostUserId)
+	if !isFound {
+		return false
+	}
+
+	db.SetSessionHost(sessionId, newHostUserId)
+	sendMessageToPlayer(staticData, &user, "became_host", nil)
+	UpdateSessionDialogs(sessionId, staticData)
+	return true
+}
+
+func EndSession(staticData *processing.StaticProccessStructs, sessionId int64) {
+	endSessionWithMessage(staticData, sessionId, "session_ended_by_host")
+}
+
+func endSessionWithMessage(staticData *processing.StaticProccessStructs, sessionId int64, textId string) {
+	db := GetDb(staticData)
+	users := db.GetUsersInSessionInfo(sessionId)
+
+	// the web users are deleted with the session, so their languages are needed before that
+	for _, user := range users {
+		if user.IsWebUser {
+			trans := FindTransFunction(user.UserId, staticData)
+			rememberRemovedWebPlayer(user.ChatId, trans(textId))
+		}
+	}
+
+	db.EndSession(sessionId)
+	forgetSessionPlaceholders(sessionId)
+
+	// web users are already removed, their pages will find out on the next update and show the message
+	for _, user := range users {
+		if user.IsWebUser {
+			notifyWebPlayer(user.UserId)
+		} else {
+			trans := FindTransFunction(user.UserId, staticData)
+			staticData.Chat.SendMessage(user.ChatId, trans(textId), 0, true)
+			SendNoSessionDialogToSomeone(user.UserId, user.ChatId, trans, staticData)
+		}
+	}
+}
@@ -83,6 +83,22 @@ func FindTransFunction(userId int64, staticData *processing.StaticProccessStruct
 	return translator
 }
 
+// for the texts that are shown before we know who reads them
+func GetDefaultTransFunction(staticData *processing.StaticProccessStructs) i18n.TranslateFunc {
+	config, configCastSuccess := staticData.Config.(static.StaticConfiguration)
+
+	if !configCastSuccess {
+		config = static.StaticConfiguration{}
+	}
+
+	if foundTrans, ok := staticData.Trans[config.DefaultLanguage]; ok {
+		return foundTrans
+	}
+
+	translator, _ := i18n.Tfunc(config.DefaultLanguage)
+	return translator
+}
+
 func FormatTimestamp(timestamp time.Time, timezone string) string {
 	// the list of timezones https://en.wikipedia.org/wiki/List_of_tz_database_time_zones
 	loc, err := time.LoadLocation(timezone)
@@ -108,15 +124,19 @@ func SendSessionDialog(data *processing.ProcessData) {
 	SendSessionDialogToSomeone(data.UserId, data.ChatId, data.Trans, data.Static)
 }
 
-func SendNoSessionDialog(data *processing.ProcessData) {
-	db := GetDb(data.Static)
-	oldMessageId, isFound := db.GetSessionMessageId(data.UserId)
+func SendNoSessionDialogToSomeone(userId int64, chatId int64, trans i18n.TranslateFunc, staticData *processing.StaticProccessStructs) {
+	db := GetDb(staticData)
+	oldMessageId, isFound := db.GetSessionMessageId(userId)
 	if isFound {
-		data.Static.Chat.RemoveMessage(data.ChatId, oldMessageId)
+		staticData.Chat.RemoveMessage(chatId, oldMessageId)
 	}
 
-	newMssageId := data.SendDialog(data.Static.MakeDialogFn("ns", data.UserId, data.Trans, data.Static, nil))
-	db.SetSessionMessageId(data.UserId, newMssageId)
+	newMssageId := staticData.Chat.SendDialog(chatId, staticData.MakeDialogFn("ns", userId, trans, staticData, nil), 0)
+	db.SetSessionMessageId(userId, newMssageId)
+}
+
+func SendNoSessionDialog(data *processing.ProcessData) {
+	SendNoSessionDialogToSomeone(data.UserId, data.ChatId, data.Trans, data.Static)
 }
 
 func UpdateSessionDialogs(sessionId int64, staticData *processing.StaticProccessStructs) {
@@ -133,6 +153,8 @@ func UpdateSessionDialogs(sessionId int64, staticData *processing.StaticProccess
 			}
 		}
 	}
+
+	notifyWebPlayers(users)
 }
 
 func ResendSessionDialogs(sessionId int64, staticData *processing.StaticProccessStructs) {
@@ -147,6 +169,8 @@ func ResendSessionDialogs(sessionId int64, staticData *processing.StaticProccess
 			SendSessionDialogToSomeone(userId, chatId, trans, staticData)
 		}
 	}
+
+	notifyWebPlayers(users)
 }
 
 func ConnectToSession(data *processing.ProcessData, token string) (successful bool) {
@@ -156,7 +180,7 @@ func ConnectToSession(data *processing.ProcessData, token string) (successful bo
 		return false
 	}
 
-	successfullyConnected, previousSessionId, wasInSession := db.ConnectToSession(data.UserId, sessionId)
+	successfullyConnected, previousSessionId, wasInSession, isPreviousSessionAbandoned := db.ConnectToSession(data.UserId, sessionId)
 	if !successfullyConnected {
 		return false
 	}
@@ -164,32 +188,78 @@ func ConnectToSession(data *processing.ProcessData, token string) (successful bo
 	UpdateSessionDialogs(sessionId, data.Static)
 
 	if wasInSession {
-		UpdateSessionDialogs(previousSessionId, data.Static)
+		UpdateLeftSession(data.Static, previousSessionId, isPreviousSessionAbandoned)
 	}
 
 	return true
 }
 
-func GetGenderNameFromId(gender int, trans i18n.TranslateFunc) string {
-	if gender == 1 {
-		return trans("gender_female")
-	} else if gender == 2 {
-		return trans("gender_male")
-	} else if gender == 3 {
-		return trans("gender_both")
-	} else {
-		return trans("gender_none")
+// the participation groups in the order they are shown to the players
+func GetGroups(staticData *processing.StaticProccessStructs) []static.GroupInfo {
+	config, configCastSuccess := staticData.Config.(static.StaticConfiguration)
+
+	if !configCastSuccess {
+		config = static.StaticConfiguration{}
 	}
+
+	return config.Groups
 }
 
-func GetGenderPlaceholderFromId(gender int, trans i18n.TranslateFunc) string {
-	if gender == 1 {
-		return trans("gender_female")
-	} else if gender == 2 {
-		return trans("gender_male")
-	} else {
-		return trans("gender_any")
+func IsGroupAvailable(staticData *processing.StaticProccessStructs, key string) bool {
+	for _, group := range GetGroups(staticData) {
+		if group.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func GetGroupName(group *static.GroupInfo, trans i18n.TranslateFunc) string {
+	id := "group_" + group.Key
+	// go-i18n returns the id when there is no translation for it
+	if name := trans(id); name != id {
+		return name
+	}
+	return group.Name
+}
+
+// returns the names of the groups joined with commas in the order they are configured
+func GetGroupNames(staticData *processing.StaticProccessStructs, keys []string, trans i18n.TranslateFunc) string {
+	var names []string
+	groups := GetGroups(staticData)
+	for i := range groups {
+		if containsString(keys, groups[i].Key) {
+			names = append(names, GetGroupName(&groups[i], trans))
+		}
+	}
+
+	if len(names) == 0 {
+		return trans("no_groups")
 	}
+	return strings.Join(names, ", ")
+}
+
+// returns false if there is no such group in the configuration
+func SetUserGroupSelected(staticData *processing.StaticProccessStructs, userId int64, key string, isSelected bool) bool {
+	if !IsGroupAvailable(staticData, key) {
+		return false
+	}
+
+	db := GetDb(staticData)
+
+	groups := make([]string, 0)
+	for _, group := range db.GetUserGroups(userId) {
+		if group != key {
+			groups = append(groups, group)
+		}
+	}
+
+	if isSelected {
+		groups = append(groups, key)
+	}
+
+	db.SetUserGroups(userId, groups)
+	return true
 }
 
 func FirstSetUpStep1(data *processing.ProcessData) (inProgress bool) {
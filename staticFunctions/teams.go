@@ -0,0 +1,165 @@
+package staticFunctions
+
+import (
+	"github.com/gameraccoon/telegram-bot-skeleton/processing"
+	"github.com/gameraccoon/telegram-the-king-says-bot/database"
+	static "github.com/gameraccoon/telegram-the-king-says-bot/staticData"
+	"github.com/nicksnyder/go-i18n/i18n"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+const MinTeamsCount = 2
+
+// each team needs its own placeholder, so there can't be more teams than the placeholders
+func GetMaxTeamsCount(staticData *processing.StaticProccessStructs, sessionId int64) int {
+	return len(GetSessionPlaceholders(staticData, sessionId).Teams)
+}
+
+func getTeamUsers(users []database.SessionUserInfo, team int) (teamUsers []database.SessionUserInfo) {
+	if team == 0 {
+		return
+	}
+
+	for _, user := range users {
+		if user.Team == team {
+			teamUsers = append(teamUsers, user)
+		}
+	}
+	return
+}
+
+// the team of the player named first in the dare, zero if nobody is named or the player is not in a team
+func getFirstNamedTeam(matches []placeholderMatch, users []database.SessionUserInfo) int {
+	// the matches are sorted from the end of the text
+	for i := len(matches) - 1; i >= 0; i-- {
+		if matches[i].userId == 0 {
+			continue
+		}
+
+		user, _ := findUserInfo(users, matches[i].userId)
+		return user.Team
+	}
+	return 0
+}
+
+// returns a random team that is not the excluded one, zero if there is no such team
+func pickOtherTeam(teamsCount int, excludedTeam int) int {
+	var teams []int
+	for team := 1; team <= teamsCount; team++ {
+		if team != excludedTeam {
+			teams = append(teams, team)
+		}
+	}
+
+	if len(teams) == 0 {
+		return 0
+	}
+	return teams[rand.Intn(len(teams))]
+}
+
+// the index of the first group of the player in the configured order, the players without groups go last
+func getFirstGroupIndex(user *database.SessionUserInfo, groups []static.GroupInfo) int {
+	for i, group := range groups {
+		if containsString(user.Groups, group.Key) {
+			return i
+		}
+	}
+	return len(groups)
+}
+
+// deals the shuffled players to the teams one by one, so the sizes of the teams differ by one at most
+// when the teams are balanced by groups, the players of each group are dealt one after another
+// to spread every group evenly between the teams
+func splitIntoTeams(users []database.SessionUserInfo, teamsCount int, groups []static.GroupInfo, isBalanced bool) (teams [][]int64) {
+	shuffledUsers := make([]database.SessionUserInfo, len(users))
+	copy(shuffledUsers, users)
+	rand.Shuffle(len(shuffledUsers), func(i, j int) { shuffledUsers[i], shuffledUsers[j] = shuffledUsers[j], shuffledUsers[i] })
+
+	if isBalanced {
+		sort.SliceStable(shuffledUsers, func(i, j int) bool {
+			return getFirstGroupIndex(&shuffledUsers[i], groups) < getFirstGroupIndex(&shuffledUsers[j], groups)
+		})
+	}
+
+	teams = make([][]int64, teamsCount)
+	for i, user := range shuffledUsers {
+		teams[i%teamsCount] = append(teams[i%teamsCount], user.UserId)
+	}
+
+	// the first teams get the extra players, so the teams are shuffled to not give them to the same team
+	rand.Shuffle(len(teams), func(i, j int) { teams[i], teams[j] = teams[j], teams[i] })
+	return
+}
+
+func getTeamName(placeholders *static.PlaceholderInfos, team int, trans i18n.TranslateFunc) string {
+	name := trans("team_name", map[string]interface{}{
+		"Team": team,
+	})
+
+	// the players need to know which placeholder names their team
+	if team <= len(placeholders.Teams) && len(placeholders.Teams[team-1].Values) > 0 {
+		name = placeholders.Teams[team-1].Values[0] + " " + name
+	}
+	return name
+}
+
+// lists the players of each team, one team on a line
+func formatTeams(placeholders *static.PlaceholderInfos, users []database.SessionUserInfo, teamsCount int, trans i18n.TranslateFunc) string {
+	lines := make([]string, 0, teamsCount)
+	for team := 1; team <= teamsCount; team++ {
+		lines = append(lines, trans("team_line", map[string]interface{}{
+			"Team":    getTeamName(placeholders, team, trans),
+			"Players": joinUserNames(getTeamUsers(users, team)),
+		}))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// returns the team of the player with its placeholder, empty if the player is not in a team
+func GetPlayerTeamName(staticData *processing.StaticProccessStructs, sessionId int64, userId int64, trans i18n.TranslateFunc) string {
+	user, isFound := findUserInfo(GetDb(staticData).GetUsersInSessionInfo(sessionId), userId)
+	if !isFound || user.Team == 0 {
+		return ""
+	}
+	return getTeamName(GetSessionPlaceholders(staticData, sessionId), user.Team, trans)
+}
+
+// the players who sit out are left without a team
+// returns false if there are not enough players or placeholders for this many teams
+func SplitSessionIntoTeams(staticData *processing.StaticProccessStructs, sessionId int64, teamsCount int, isBalanced bool) (isSucceeded bool) {
+	db := GetDb(staticData)
+
+	activeUsers := getActiveUsers(db.GetUsersInSessionInfo(sessionId))
+	if teamsCount < MinTeamsCount || teamsCount > GetMaxTeamsCount(staticData, sessionId) || len(activeUsers) < teamsCount {
+		return false
+	}
+
+	db.SetSessionTeams(sessionId, splitIntoTeams(activeUsers, teamsCount, GetGroups(staticData), isBalanced))
+
+	placeholders := GetSessionPlaceholders(staticData, sessionId)
+	users := db.GetUsersInSessionInfo(sessionId)
+	for i := range users {
+		trans := FindTransFunction(users[i].UserId, staticData)
+		sendTextToPlayer(staticData, &users[i], trans("teams_split", map[string]interface{}{
+			"Teams": formatTeams(placeholders, users, teamsCount, trans),
+		}))
+	}
+
+	UpdateSessionDialogs(sessionId, staticData)
+	return true
+}
+
+func RemoveSessionTeams(staticData *processing.StaticProccessStructs, sessionId int64) {
+	db := GetDb(staticData)
+
+	db.SetSessionTeams(sessionId, nil)
+
+	users := db.GetUsersInSessionInfo(sessionId)
+	for i := range users {
+		sendMessageToPlayer(staticData, &users[i], "teams_removed", nil)
+	}
+
+	UpdateSessionDialogs(sessionId, staticData)
+}
@@ -0,0 +1,109 @@
+package staticFunctions
+
+import (
+	"sync"
+	"time"
+)
+
+// the open update streams of the web pages, several pages of the same player can be open at once
+var webUpdateListeners = struct {
+	mutex    sync.Mutex
+	channels map[int64][]chan struct{}
+}{
+	channels: make(map[int64][]chan struct{}),
+}
+
+// the returned channel receives a value when something changes for the player
+// the updates that come while the previous one is not processed yet are merged into one
+func SubscribeToWebUpdates(userId int64) (updates <-chan struct{}, unsubscribe func()) {
+	channel := make(chan struct{}, 1)
+
+	webUpdateListeners.mutex.Lock()
+	defer webUpdateListeners.mutex.Unlock()
+
+	webUpdateListeners.channels[userId] = append(webUpdateListeners.channels[userId], channel)
+
+	unsubscribe = func() {
+		webUpdateListeners.mutex.Lock()
+		defer webUpdateListeners.mutex.Unlock()
+
+		channels := webUpdateListeners.channels[userId]
+		for i, userChannel := range channels {
+			if userChannel == channel {
+				channels = append(channels[:i], channels[i+1:]...)
+				break
+			}
+		}
+
+		if len(channels) > 0 {
+			webUpdateListeners.channels[userId] = channels
+		} else {
+			delete(webUpdateListeners.channels, userId)
+		}
+	}
+	return channel, unsubscribe
+}
+
+// the players without open pages are skipped, so it's fine to pass Telegram players too
+func notifyWebPlayers(userIds []int64) {
+	webUpdateListeners.mutex.Lock()
+	defer webUpdateListeners.mutex.Unlock()
+
+	for _, userId := range userIds {
+		for _, channel := range webUpdateListeners.channels[userId] {
+			select {
+			case channel <- struct{}{}:
+			default:
+				// there is already an update waiting to be sent
+			}
+		}
+	}
+}
+
+func notifyWebPlayer(userId int64) {
+	notifyWebPlayers([]int64{userId})
+}
+
+// the web players are deleted when they leave the session, so the reason is kept for a while for their pages
+const removedWebPlayerMemoryTime = time.Hour
+
+type removedWebPlayer struct {
+	message   string
+	removedAt time.Time
+}
+
+var removedWebPlayers = struct {
+	mutex   sync.Mutex
+	players map[int64]removedWebPlayer
+}{
+	players: make(map[int64]removedWebPlayer),
+}
+
+func rememberRemovedWebPlayer(token int64, message string) {
+	removedWebPlayers.mutex.Lock()
+	defer removedWebPlayers.mutex.Unlock()
+
+	// the pages of most of the players are closed long before, so forget them
+	for oldToken, player := range removedWebPlayers.players {
+		if time.Since(player.removedAt) > removedWebPlayerMemoryTime {
+			delete(removedWebPlayers.players, oldToken)
+		}
+	}
+
+	removedWebPlayers.players[token] = removedWebPlayer{
+		message:   message,
+		removedAt: time.Now(),
+	}
+}
+
+// returns the message explaining why the player was removed from their session, if it's still remembered
+func GetRemovedWebPlayerMessage(token int64) (message string, isFound bool) {
+	removedWebPlayers.mutex.Lock()
+	defer removedWebPlayers.mutex.Unlock()
+
+	player, isFound := removedWebPlayers.players[token]
+	if !isFound || time.Since(player.removedAt) > removedWebPlayerMemoryTime {
+		return "", false
+	}
+	return player.message, true
+}
@@ -10,7 +10,13 @@ import (
 	"time"
 )
 
-type userChannel chan *processing.ProcessData
+type userUpdate struct {
+	data *processing.ProcessData
+	// a file sent by the user, if any
+	document *tgbotapi.Document
+}
+
+type userChannel chan *userUpdate
 
 type userChannelData struct {
 	channel userChannel
@@ -56,6 +62,12 @@ func processMessageUpdate(userChans userChannelsData, update *tgbotapi.Update, s
 		UserSystemName: update.Message.From.FirstName,
 	}
 
+	if update.Message.Document != nil {
+		data.Message = update.Message.Caption
+		processUpdate(userChans, &userUpdate{data: &data, document: update.Message.Document}, dialogManager, processors)
+		return
+	}
+
 	message := update.Message.Text
 
 	if strings.HasPrefix(message, "/") {
@@ -70,7 +82,7 @@ func processMessageUpdate(userChans userChannelsData, update *tgbotapi.Update, s
 		data.Message = message
 	}
 
-	processUpdate(userChans, &data, dialogManager, processors)
+	processUpdate(userChans, &userUpdate{data: &data}, dialogManager, processors)
 }
 
 func processCallbackUpdate(userChans userChannelsData, update *tgbotapi.Update, staticData *processing.StaticProccessStructs, dialogManager *dialogManager.DialogManager, processors *ProcessorFuncMap) {
@@ -91,17 +103,17 @@ func processCallbackUpdate(userChans userChannelsData, update *tgbotapi.Update,
 		data.Command = message[1:]
 	}
 
-	processUpdate(userChans, &data, dialogManager, processors)
+	processUpdate(userChans, &userUpdate{data: &data}, dialogManager, processors)
 }
 
-func processUpdate(userChans userChannelsData, data *processing.ProcessData, dialogManager *dialogManager.DialogManager, processors *ProcessorFuncMap) {
-	userChanData, found := userChans[data.ChatId]
+func processUpdate(userChans userChannelsData, update *userUpdate, dialogManager *dialogManager.DialogManager, processors *ProcessorFuncMap) {
+	userChanData, found := userChans[update.data.ChatId]
 
 	if !found || userChanData == nil {
 		userChanData = &userChannelData{
 			channel: make(userChannel),
 		}
-		userChans[data.ChatId] = userChanData
+		userChans[update.data.ChatId] = userChanData
 
 		// start updates for a user
 		go processUserUpdatesParallel(userChanData.channel, dialogManager, processors)
@@ -110,26 +122,28 @@ func processUpdate(userChans userChannelsData, data *processing.ProcessData, dia
 	userChanData.lastUpdateTime = time.Now()
 
 	// send in parallel to not wait
-	go sendUpdate(userChanData.channel, data)
+	go sendUpdate(userChanData.channel, update)
 }
 
-func sendUpdate(userChan userChannel, data *processing.ProcessData) {
-	userChan <- data
+func sendUpdate(userChan userChannel, update *userUpdate) {
+	userChan <- update
 }
 
 func processUserUpdatesParallel(userChan userChannel, dialogManager *dialogManager.DialogManager, processors *ProcessorFuncMap) {
 	for {
-		updateData, chanIsOk := <-userChan
+		update, chanIsOk := <-userChan
 
 		if !chanIsOk {
 			log.Print("Close channel")
 			return
 		}
 
-		if len(updateData.Command) > 0 {
-			processCommand(updateData, dialogManager, processors)
+		if update.document != nil {
+			processDocument(update.data, update.document)
+		} else if len(update.data.Command) > 0 {
+			processCommand(update.data, dialogManager, processors)
 		} else {
-			processPlainMessage(updateData, dialogManager)
+			processPlainMessage(update.data, dialogManager)
 		}
 	}
 }